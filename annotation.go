@@ -0,0 +1,56 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WithAnnotation adds opaque metadata, e.g. tenant ID, carried through
+// hooks, router, audit records and debug logs. It is available as
+// Annotation of http.Request and Response.Annotation and is not sent.
+func (r *Request) WithAnnotation(key, value any) requester {
+	if r.annotations == nil {
+		r.annotations = make(map[any]any)
+	}
+	r.annotations[key] = value
+	return r
+}
+
+// Annotation returns annotation of request sent by restreq, e.g. in
+// OnBeforeRequest hook or http.RoundTripper. It returns nil if the
+// annotation is not set.
+func Annotation(req *http.Request, key any) any {
+	m, _ := req.Context().Value(annotationKey{}).(map[any]any)
+	return m[key]
+}
+
+// Annotation returns annotation set by WithAnnotation,
+// nil if it is not set.
+func (r *Response) Annotation(key any) any {
+	return r.annotations[key]
+}
+
+type annotationKey struct{}
+
+func (r *Request) annotate(ctx context.Context) context.Context {
+	if len(r.annotations) == 0 {
+		return ctx
+	}
+	for k, v := range r.annotations {
+		r.debug(ReqHeaders, fmt.Sprintf("Annotation: %v: %v", k, v))
+	}
+	return context.WithValue(ctx, annotationKey{}, r.annotations)
+}
+
+// annotationStrings formats annotations for audit records.
+func (r *Request) annotationStrings() map[string]string {
+	if len(r.annotations) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(r.annotations))
+	for k, v := range r.annotations {
+		m[fmt.Sprint(k)] = fmt.Sprint(v)
+	}
+	return m
+}
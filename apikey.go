@@ -0,0 +1,41 @@
+package restreq
+
+import "net/http"
+
+// APIKeyLocation tells where SetAPIKey puts the key, like "in"
+// of OpenAPI apiKey security scheme.
+type APIKeyLocation int
+
+const (
+	APIKeyHeader APIKeyLocation = iota
+	APIKeyQuery
+	APIKeyCookie
+)
+
+type apiKey struct {
+	name  string
+	value string
+	in    APIKeyLocation
+}
+
+// SetAPIKey sends API key value as header, query parameter
+// or cookie name, e.g. SetAPIKey("X-API-Key", key, APIKeyHeader).
+func (r *Request) SetAPIKey(name, value string, in APIKeyLocation) requester {
+	switch in {
+	case APIKeyHeader:
+		r.headers[name] = value
+	case APIKeyQuery:
+		r.SetQueryParams(map[string]string{name: value})
+	case APIKeyCookie:
+		r.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return r
+}
+
+// SetAPIKey sends API key with every request.
+func (c *Client) SetAPIKey(name, value string, in APIKeyLocation) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = &apiKey{name: name, value: value, in: in}
+	return c
+}
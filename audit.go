@@ -0,0 +1,176 @@
+package restreq
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord describes a single sent request. Sensitive headers and
+// query parameters are redacted.
+type AuditRecord struct {
+	Time       time.Time         `json:"time"`
+	Principal  string            `json:"principal,omitempty"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Header     map[string]string `json:"header,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+	Error      string            `json:"error,omitempty"`
+	Annotation map[string]string `json:"annotation,omitempty"`
+	PrevHash   string            `json:"prev_hash,omitempty"`
+	Hash       string            `json:"hash,omitempty"`
+}
+
+// AuditSink receives a record for every sent request.
+// If Audit returns an error, the request returns the response and the error.
+type AuditSink interface {
+	Audit(AuditRecord) error
+}
+
+// SetAuditSink sets sink receiving audit records.
+func (r *Request) SetAuditSink(s AuditSink) requester {
+	r.auditSink = s
+	return r
+}
+
+// SetAuditSink sets sink receiving audit records of every request.
+func (c *Client) SetAuditSink(s AuditSink) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auditSink = s
+	return c
+}
+
+func (r *Request) audit(req *http.Request, resp *http.Response, err error, d time.Duration) error {
+	rec := AuditRecord{
+		Time:      time.Now().Add(-d),
+		Principal: r.username,
+		Method:    req.Method,
+		URL:       r.scrubber.URL(redactURL(req.URL)),
+		Header:    make(map[string]string, len(req.Header)),
+		Duration:  d,
+
+		Annotation: r.annotationStrings(),
+	}
+
+	for k := range req.Header {
+		rec.Header[k] = r.scrubber.Header(k, redactHeader(k, req.Header.Get(k)))
+	}
+	if resp != nil {
+		rec.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if err := r.auditSink.Audit(rec); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	return nil
+}
+
+const redacted = "[REDACTED]"
+
+var sensitiveNames = []string{"authorization", "cookie", "token", "secret", "password", "key", "signature"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactHeader(k, v string) string {
+	if isSensitive(k) {
+		return redacted
+	}
+	return v
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+
+	q := c.Query()
+	for k := range q {
+		if isSensitive(k) {
+			q.Set(k, redacted)
+		}
+	}
+	if len(q) > 0 {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// HashChain links records with SHA-256 hashes, so any modification,
+// removal or reordering of stored records can be detected
+// with VerifyAuditChain.
+type HashChain struct {
+	mu   sync.Mutex
+	sink AuditSink
+	prev string
+}
+
+// NewHashChain creates chain passing linked records to sink.
+// prev is the hash of the last stored record, empty for a new chain.
+func NewHashChain(sink AuditSink, prev string) *HashChain {
+	return &HashChain{sink: sink, prev: prev}
+}
+
+// Audit sets PrevHash and Hash of the record and passes it to the sink.
+func (h *HashChain) Audit(rec AuditRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rec.PrevHash = h.prev
+	hash, err := auditHash(rec)
+	if err != nil {
+		return err
+	}
+	rec.Hash = hash
+
+	if err := h.sink.Audit(rec); err != nil {
+		return err
+	}
+	h.prev = hash
+	return nil
+}
+
+// VerifyAuditChain checks hashes of records stored by HashChain.
+func VerifyAuditChain(records []AuditRecord, prev string) error {
+	for i, rec := range records {
+		if rec.PrevHash != prev {
+			return fmt.Errorf("audit record %d: chain broken", i)
+		}
+		hash, err := auditHash(rec)
+		if err != nil {
+			return err
+		}
+		if rec.Hash != hash {
+			return fmt.Errorf("audit record %d: hash mismatch", i)
+		}
+		prev = hash
+	}
+	return nil
+}
+
+func auditHash(rec AuditRecord) (string, error) {
+	rec.Hash = ""
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,91 @@
+package restreq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type auditRecords []AuditRecord
+
+func (a *auditRecords) Audit(rec AuditRecord) error {
+	*a = append(*a, rec)
+	return nil
+}
+
+func TestHashChain(t *testing.T) {
+	var stored auditRecords
+	chain := NewHashChain(&stored, "")
+	for i, m := range []string{"GET", "POST", "DELETE"} {
+		rec := AuditRecord{Time: time.Unix(int64(i), 0).UTC(), Method: m, URL: "https://example.com/items"}
+		if err := chain.Audit(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := VerifyAuditChain(stored, ""); err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].PrevHash != "" || stored[1].PrevHash != stored[0].Hash {
+		t.Fatalf("records not linked: %+v", stored)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]AuditRecord) []AuditRecord
+		prev   string
+		want   string
+	}{
+		{"modified", func(r []AuditRecord) []AuditRecord { r[1].Method = "PUT"; return r }, "", "record 1: hash mismatch"},
+		{"rehashed", func(r []AuditRecord) []AuditRecord {
+			r[1].Method = "PUT"
+			r[1].Hash, _ = auditHash(r[1])
+			return r
+		}, "", "record 2: chain broken"},
+		{"removed", func(r []AuditRecord) []AuditRecord { return append(r[:1], r[2:]...) }, "", "record 1: chain broken"},
+		{"reordered", func(r []AuditRecord) []AuditRecord { r[1], r[2] = r[2], r[1]; return r }, "", "record 1: chain broken"},
+		{"truncated head", func(r []AuditRecord) []AuditRecord { return r[1:] }, "", "record 0: chain broken"},
+		{"continued", func(r []AuditRecord) []AuditRecord { return r[1:] }, stored[0].Hash, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := tt.tamper(append([]AuditRecord(nil), stored...))
+			err := VerifyAuditChain(records, tt.prev)
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var stored auditRecords
+	_, err := New(srv.URL+"/items?api_key=k1&page=2").
+		SetAuditSink(&stored).
+		SetBasicAuth("alice", "pw").
+		AddHeader("X-Session-Token", "t1").
+		AddHeader("X-Request-Id", "r1").
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("%d records", len(stored))
+	}
+	rec := stored[0]
+	if rec.Principal != "alice" || rec.Method != "GET" || rec.StatusCode != http.StatusAccepted {
+		t.Errorf("record %+v", rec)
+	}
+	if rec.Header["Authorization"] != redacted || rec.Header["X-Session-Token"] != redacted || rec.Header["X-Request-Id"] != "r1" {
+		t.Errorf("header %v", rec.Header)
+	}
+	if !strings.HasSuffix(rec.URL, "/items?api_key=%5BREDACTED%5D&page=2") {
+		t.Errorf("URL %s", rec.URL)
+	}
+}
@@ -0,0 +1,83 @@
+package restreq
+
+import "context"
+
+// TokenProvider returns bearer token, resolved every time a request
+// is sent, e.g. from Vault, workload identity or refresh token flow.
+// It should cache tokens itself.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts function to TokenProvider.
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token calls f.
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// SetBearerToken sets Authorization header with bearer token.
+func (r *Request) SetBearerToken(token string) requester {
+	return r.SetAuthScheme("Bearer", token)
+}
+
+// SetAuthScheme sets Authorization header with scheme and
+// credentials, e.g. SetAuthScheme("Token", t). Like other auth
+// setters, it replaces auth set before or inherited from the client.
+func (r *Request) SetAuthScheme(scheme, credentials string) requester {
+	r.clearAuth()
+	r.headers["Authorization"] = scheme + " " + credentials
+	return r
+}
+
+// SetBearerToken sets Authorization header with bearer token
+// for every request.
+func (c *Client) SetBearerToken(token string) *Client {
+	return c.SetAuthScheme("Bearer", token)
+}
+
+// SetAuthScheme sets Authorization header with scheme and
+// credentials for every request.
+func (c *Client) SetAuthScheme(scheme, credentials string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAuth()
+	c.headers["Authorization"] = scheme + " " + credentials
+	return c
+}
+
+// SetTokenProvider makes the request send bearer token from p.
+func (r *Request) SetTokenProvider(p TokenProvider) requester {
+	r.clearAuth()
+	r.bearer = p
+	return r
+}
+
+// SetTokenProvider makes every request send bearer token from p.
+func (c *Client) SetTokenProvider(p TokenProvider) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAuth()
+	c.bearer = p
+	return c
+}
+
+// clearAuth removes auth of the request, so auth setters
+// replace each other.
+func (r *Request) clearAuth() {
+	r.username, r.password = "", ""
+	r.secretAuth = nil
+	r.bearer = nil
+	r.digest = nil
+	delete(r.headers, "Authorization")
+}
+
+// clearAuth removes auth of the client.
+func (c *Client) clearAuth() {
+	c.username, c.password = "", ""
+	c.secretAuth = nil
+	c.bearer = nil
+	c.digest = nil
+	delete(c.headers, "Authorization")
+}
@@ -0,0 +1,124 @@
+package restreq
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+)
+
+var testSecrets = CredentialStoreFunc(func(ctx context.Context, name string) (string, error) {
+	return "secret-" + name, nil
+})
+
+var testTokens = TokenProviderFunc(func(ctx context.Context) (string, error) {
+	return "provided", nil
+})
+
+func basicHeader(user, pass string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+}
+
+type authSetter struct {
+	name    string
+	client  func(*Client)
+	request func(*Request)
+	// want is Authorization sent without a challenge, digest sends none.
+	want string
+}
+
+var authSetters = []authSetter{
+	{
+		name:    "basic",
+		client:  func(c *Client) { c.SetAuth("u", "p") },
+		request: func(r *Request) { r.SetBasicAuth("u", "p") },
+		want:    basicHeader("u", "p"),
+	},
+	{
+		name:    "secret",
+		client:  func(c *Client) { c.SetAuthSecret(testSecrets, "s", "n") },
+		request: func(r *Request) { r.SetBasicAuthSecret(testSecrets, "s", "n") },
+		want:    basicHeader("s", "secret-n"),
+	},
+	{
+		name:    "bearer",
+		client:  func(c *Client) { c.SetBearerToken("tok") },
+		request: func(r *Request) { r.SetBearerToken("tok") },
+		want:    "Bearer tok",
+	},
+	{
+		name:    "scheme",
+		client:  func(c *Client) { c.SetAuthScheme("Token", "t") },
+		request: func(r *Request) { r.SetAuthScheme("Token", "t") },
+		want:    "Token t",
+	},
+	{
+		name:    "provider",
+		client:  func(c *Client) { c.SetTokenProvider(testTokens) },
+		request: func(r *Request) { r.SetTokenProvider(testTokens) },
+		want:    "Bearer provided",
+	},
+	{
+		name:    "digest",
+		client:  func(c *Client) { c.SetDigestAuth("d", "p") },
+		request: func(r *Request) { r.SetDigestAuth("d", "p") },
+		want:    "",
+	},
+}
+
+func buildAuth(t *testing.T, r *Request) string {
+	t.Helper()
+	req, err := r.Build("GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req.Header.Get("Authorization")
+}
+
+func TestRequestAuthReplacesClientAuth(t *testing.T) {
+	for _, c := range authSetters {
+		for _, r := range authSetters {
+			t.Run(c.name+"/"+r.name, func(t *testing.T) {
+				client := NewClient("http://example.com")
+				c.client(client)
+				req := client.New("/")
+				r.request(req)
+				if got := buildAuth(t, req); got != r.want {
+					t.Errorf("Authorization = %q, want %q", got, r.want)
+				}
+				if (req.digest != nil) != (r.name == "digest") {
+					t.Errorf("digest auth kept = %v", req.digest != nil)
+				}
+			})
+		}
+	}
+}
+
+func TestClientAuthReplacesClientAuth(t *testing.T) {
+	for _, first := range authSetters {
+		for _, second := range authSetters {
+			t.Run(first.name+"/"+second.name, func(t *testing.T) {
+				client := NewClient("http://example.com")
+				first.client(client)
+				second.client(client)
+				if got := buildAuth(t, client.New("/")); got != second.want {
+					t.Errorf("Authorization = %q, want %q", got, second.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRequestAuthReplacesRequestAuth(t *testing.T) {
+	for _, first := range authSetters {
+		for _, second := range authSetters {
+			t.Run(first.name+"/"+second.name, func(t *testing.T) {
+				req := New("http://example.com")
+				first.request(req)
+				second.request(req)
+				if got := buildAuth(t, req); got != second.want {
+					t.Errorf("Authorization = %q, want %q", got, second.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package restreq
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns wait before retry attempt, counted from 0.
+// prev is the previous wait returned by Backoff, 0 before
+// the first retry. Zero base and max use default retry waits.
+//
+// Backoff can be used also by application polling loops:
+//
+//	b := restreq.DecorrelatedJitter(time.Second, time.Minute)
+//	var d time.Duration
+//	for i := 0; !done(); i++ {
+//		d = b(i, d)
+//		time.Sleep(d)
+//	}
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// FullJitter returns backoff with random wait between 0 and
+// the exponential wait.
+func FullJitter(base, max time.Duration) Backoff {
+	base, max = backoffLimits(base, max)
+	return func(attempt int, _ time.Duration) time.Duration {
+		return jitter(0, exponential(base, max, attempt))
+	}
+}
+
+// EqualJitter returns backoff with random wait between a half of
+// the exponential wait and the exponential wait. It is used by default.
+func EqualJitter(base, max time.Duration) Backoff {
+	base, max = backoffLimits(base, max)
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := exponential(base, max, attempt)
+		return jitter(d/2, d)
+	}
+}
+
+// DecorrelatedJitter returns backoff with random wait between base
+// and three times the previous wait, limited to max.
+func DecorrelatedJitter(base, max time.Duration) Backoff {
+	base, max = backoffLimits(base, max)
+	return func(_ int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+		upper := prev * 3
+		if upper > max || upper < prev {
+			upper = max
+		}
+		return jitter(base, upper)
+	}
+}
+
+// SetRetryBackoff sets backoff strategy between retries, instead of
+// equal jitter with SetRetryWaitTime and SetRetryMaxWaitTime.
+// Retry-After of the response is still preferred.
+func (r *Request) SetRetryBackoff(b Backoff) requester {
+	r.retryBackoff = b
+	return r
+}
+
+// SetRetryBackoff sets backoff strategy between retries of every request.
+func (c *Client) SetRetryBackoff(b Backoff) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryBackoff = b
+	return c
+}
+
+func backoffLimits(base, max time.Duration) (time.Duration, time.Duration) {
+	if base <= 0 {
+		base = DefaultRetryWaitTime
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxWaitTime
+	}
+	return base, max
+}
+
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// jitter returns random duration between min and max.
+func jitter(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	return min + time.Duration(rand.Int63n(int64(max-min)+1))
+}
@@ -0,0 +1,267 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+)
+
+// SetJSONArrayStream streams JSON array to request body, element by
+// element as they are received from ch, without building a slice.
+// The array is closed when ch is closed, so the producer must close it.
+func (r *Request) SetJSONArrayStream(ch <-chan any) requester {
+	r.jsonStream = ch
+	return r
+}
+
+// SetBodyReader streams request body from rd, without buffering it.
+// It replaces JSON body. rd is closed if it implements io.Closer, when
+// the body is sent or the request fails, also when it is compressed.
+// Requests with body reader are not retried. Body of unknown length
+// is sent chunked, unless SetContentLength is used. Length of
+// *bytes.Buffer, *bytes.Reader and *strings.Reader is known.
+func (r *Request) SetBodyReader(rd io.Reader) requester {
+	r.bodySource = rd
+	return r
+}
+
+// SetBodyFile sends file name of fsys as request body, e.g. from
+// embed.FS. It replaces JSON body. The file is opened for every
+// attempt, so the request can be retried. Content-Type is detected
+// from extension of name, unless it is set.
+func (r *Request) SetBodyFile(fsys fs.FS, name string) requester {
+	r.bodyFile = &fsFile{fsys: fsys, name: name}
+	if _, ok := r.headers["Content-Type"]; !ok {
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			r.headers["Content-Type"] = ct
+		}
+	}
+	return r
+}
+
+type fsFile struct {
+	fsys fs.FS
+	name string
+}
+
+// fileSize returns size of regular file, or 0.
+func fileSize(f fs.File) int64 {
+	st, err := f.Stat()
+	if err != nil || !st.Mode().IsRegular() {
+		return 0
+	}
+	return st.Size()
+}
+
+// SetBody sets request body sent verbatim, with Content-Type set
+// by SetContentType. It replaces JSON body.
+func (r *Request) SetBody(b []byte) requester {
+	r.rawBody = append([]byte{}, b...)
+	return r
+}
+
+// SetBodyString sets request body sent verbatim, with Content-Type set
+// by SetContentType. It replaces JSON body.
+func (r *Request) SetBodyString(s string) requester {
+	r.rawBody = []byte(s)
+	return r
+}
+
+// SetContentLength sets length of body set by SetBodyReader.
+func (r *Request) SetContentLength(n int64) requester {
+	r.contentLength = n
+	return r
+}
+
+// WithoutBody makes the request be sent without body,
+// even if payload is set.
+func (r *Request) WithoutBody() requester {
+	r.bodyMode = bodyNone
+	return r
+}
+
+// ForceBody makes GET, HEAD, DELETE, OPTIONS and TRACE request
+// send JSON body {} when no payload is set.
+func (r *Request) ForceBody() requester {
+	r.bodyMode = bodyForce
+	return r
+}
+
+const (
+	bodyAuto = iota
+	bodyNone
+	bodyForce
+)
+
+// bodyless reports if method is sent without body when
+// no payload is set.
+func bodyless(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// body returns request body, nil if there is none. Buffered
+// bodies are returned as *bytes.Buffer, so the length is known.
+func (r *Request) body(method string) (io.Reader, error) {
+	if r.bodyMode == bodyNone {
+		return nil, nil
+	}
+
+	if r.jsonStream != nil {
+		r.debug(ReqBody, "Body: <JSON array stream>")
+		return streamJSONArray(r.jsonStream), nil
+	}
+
+	if r.bodySource != nil {
+		r.debug(ReqBody, "Body: <reader>")
+		return r.bodySource, nil
+	}
+
+	if r.bodyFile != nil {
+		r.debug(ReqBody, fmt.Sprintf("Body: <file %s>", r.bodyFile.name))
+		return r.bodyFile.fsys.Open(r.bodyFile.name)
+	}
+
+	if r.rawBody != nil {
+		r.debug(ReqBody, fmt.Sprintf("Body: %s", bytes.TrimRight(r.scrubber.Body(r.rawBody), "\n")))
+		return bytes.NewBuffer(r.rawBody), nil
+	}
+
+	if len(r.parts) > 0 {
+		return r.multipartBody()
+	}
+
+	if r.form == nil && len(r.jsonPayload) == 0 && len(r.json) == 0 &&
+		r.bodyMode != bodyForce && bodyless(method) {
+		return nil, nil
+	}
+
+	payload := &bytes.Buffer{}
+
+	if r.form != nil {
+		f, err := r.encodeForm()
+		if err != nil {
+			return nil, err
+		}
+		payload.WriteString(f)
+	} else if len(r.jsonPayload) > 0 {
+		payload.Write(r.jsonPayload)
+	} else {
+		m := r.json
+		if len(r.defaults) > 0 {
+			m = make(map[string]any, len(r.json)+len(r.defaults))
+			for k, v := range r.defaults {
+				m[k] = v
+			}
+			for k, v := range r.json {
+				m[k] = v
+			}
+		}
+		if r.vars != nil {
+			src := m
+			m = make(map[string]any, len(src))
+			for k, v := range src {
+				x, err := r.expandValue(v)
+				if err != nil {
+					return nil, err
+				}
+				m[k] = x
+			}
+		}
+		b, err := r.marshalJSON(m)
+		if err != nil {
+			return nil, err
+		}
+		payload.Write(b)
+		payload.WriteByte('\n')
+	}
+
+	r.debug(ReqBody, fmt.Sprintf("Body: %s", bytes.TrimRight(r.scrubber.Body(payload.Bytes()), "\n")))
+	return payload, nil
+}
+
+// bodyCloser closes source body of the request together with reader
+// wrapping it, e.g. for compression or upload progress.
+type bodyCloser struct {
+	io.Reader
+	source io.Closer
+}
+
+func (b *bodyCloser) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.source.Close()
+}
+
+func streamJSONArray(ch <-chan any) io.Reader {
+	pr, pw := io.Pipe()
+
+	go func() {
+		enc := json.NewEncoder(pw)
+		sep := []byte{'['}
+
+		for v := range ch {
+			if _, err := pw.Write(sep); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if err := enc.Encode(v); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			sep = []byte{','}
+		}
+
+		if sep[0] == '[' {
+			pw.Write(sep)
+		}
+		_, err := pw.Write([]byte{']'})
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+// compress returns body compressed with encoding. Buffered body is
+// compressed at once, any other reader is compressed while it is sent.
+func compress(body io.Reader, encoding string) (io.Reader, error) {
+	c, err := compressor(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := body.(*bytes.Buffer); ok {
+		b := &bytes.Buffer{}
+		if err := compressTo(b, body, c); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(compressTo(pw, body, c))
+	}()
+	return pr, nil
+}
+
+func compressTo(w io.Writer, body io.Reader, c Compressor) error {
+	cw, err := c.Compress(w)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(cw, body); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
@@ -0,0 +1,54 @@
+package restreq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type closeCounter struct {
+	io.Reader
+	closed int32
+}
+
+func (c *closeCounter) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func TestBodyReaderClosed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		set     func(*Request)
+		wantErr bool
+	}{
+		{"plain", func(*Request) {}, false},
+		{"compressed", func(r *Request) { r.SetRequestCompression("gzip") }, false},
+		{"progress", func(r *Request) { r.OnUploadProgress(func(Progress) {}) }, false},
+		{"both", func(r *Request) { r.SetRequestCompression("gzip").OnUploadProgress(func(Progress) {}) }, false},
+		{"build error", func(r *Request) { r.SetContentDigest(DigestSHA256) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &closeCounter{Reader: strings.NewReader("payload")}
+			req := New(srv.URL)
+			req.SetBodyReader(body)
+			tt.set(req)
+			_, err := req.Post()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if atomic.LoadInt32(&body.closed) == 0 {
+				t.Error("body reader not closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package restreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Causes of cancellations started by restreq, returned by context.Cause
+// of a request context and matched by errors.Is on errors of requests.
+// They are set with Go 1.21 and later. They wrap context.DeadlineExceeded
+// or context.Canceled, which still match.
+var (
+	ErrRequestTimeout = fmt.Errorf("restreq: request timeout exceeded: %w", context.DeadlineExceeded)
+	ErrHedgeLost      = fmt.Errorf("restreq: another hedged request won: %w", context.Canceled)
+	ErrCanceledAll    = fmt.Errorf("restreq: canceled by CancelAll: %w", context.Canceled)
+)
+
+// CauseError is error of a request canceled with a cause other than
+// plain context cancellation or deadline.
+type CauseError struct {
+	Err   error
+	Cause error
+}
+
+func (e *CauseError) Error() string {
+	return e.Err.Error() + " (cause: " + e.Cause.Error() + ")"
+}
+
+func (e *CauseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports if the cause matches target.
+func (e *CauseError) Is(target error) bool {
+	return errors.Is(e.Cause, target)
+}
+
+// withCause wraps err of request with context ctx in CauseError,
+// if ctx was canceled with a cause not reported by err, and logs
+// the cause.
+func (r *Request) withCause(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	cause := contextCause(ctx)
+	if cause == nil || cause == ctx.Err() {
+		return err
+	}
+	if r.logger != nil {
+		r.logger.Printf("Canceled: %s\n", cause)
+	}
+	if errors.Is(err, cause) {
+		return err
+	}
+	return &CauseError{Err: err, Cause: cause}
+}
+
+// inflight keeps cancel funcs of requests being sent by a client.
+type inflight struct {
+	mu   sync.Mutex
+	next int
+	m    map[int]func(error)
+}
+
+// add registers cancel until the returned func is called.
+func (f *inflight) add(cancel func(error)) func(error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.m == nil {
+		f.m = make(map[int]func(error))
+	}
+	id := f.next
+	f.next++
+	f.m[id] = cancel
+	return func(cause error) {
+		f.mu.Lock()
+		delete(f.m, id)
+		f.mu.Unlock()
+		cancel(cause)
+	}
+}
+
+// CancelAll cancels requests of the client being sent, with cause
+// ErrCanceledAll. Canceled requests are not retried.
+func (c *Client) CancelAll() {
+	f := &c.inflight
+	f.mu.Lock()
+	cancels := f.m
+	f.m = nil
+	f.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel(ErrCanceledAll)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build go1.21
+
+package restreq
+
+import (
+	"context"
+	"time"
+)
+
+func withCancelCause(ctx context.Context) (context.Context, func(error)) {
+	return context.WithCancelCause(ctx)
+}
+
+func withTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, d, cause)
+}
+
+func contextCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
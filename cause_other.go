@@ -0,0 +1,23 @@
+//go:build !go1.21
+
+package restreq
+
+import (
+	"context"
+	"time"
+)
+
+// Causes are dropped before Go 1.21.
+
+func withCancelCause(ctx context.Context) (context.Context, func(error)) {
+	ctx, cancel := context.WithCancel(ctx)
+	return ctx, func(error) { cancel() }
+}
+
+func withTimeoutCause(ctx context.Context, d time.Duration, _ error) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
+}
+
+func contextCause(ctx context.Context) error {
+	return ctx.Err()
+}
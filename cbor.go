@@ -0,0 +1,433 @@
+package restreq
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// SetCBORPayload encodes map or struct to CBOR body and sets
+// Content-Type to application/cbor. Struct fields are named by
+// cbor tag or json tag. It replaces JSON body.
+func (r *Request) SetCBORPayload(p any) requester {
+	return r.SetPayload("application/cbor", p)
+}
+
+// DecodeCBOR decodes CBOR
+func (r *Response) DecodeCBOR(s any) error {
+	if ok, err := r.empty(s); ok {
+		return err
+	}
+	defer r.observeDecode(time.Now())
+	return cborCodec{}.Unmarshal(r.Body, s)
+}
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string {
+	return "application/cbor"
+}
+
+func (cborCodec) Marshal(v any) ([]byte, error) {
+	e := &cborEncoder{}
+	if err := encodeValue(e, reflect.ValueOf(v), "cbor", 0); err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return e.b, nil
+}
+
+func (cborCodec) Unmarshal(data []byte, v any) error {
+	d := &cborDecoder{b: data}
+	tree, err := d.value(0)
+	if err == nil && len(d.b) > 0 {
+		err = fmt.Errorf("%d bytes after value", len(d.b))
+	}
+	if err == nil {
+		err = decodeInto(tree, v, "cbor")
+	}
+	if err != nil {
+		return fmt.Errorf("cbor: %w", err)
+	}
+	return nil
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+type cborEncoder struct {
+	b []byte
+}
+
+func (e *cborEncoder) head(major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		e.b = append(e.b, m|byte(n))
+	case n <= math.MaxUint8:
+		e.b = append(e.b, m|24, byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, m|26), uint32(n))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, m|27), n)
+	}
+}
+
+func (e *cborEncoder) writeNil() {
+	e.b = append(e.b, 0xf6)
+}
+
+func (e *cborEncoder) writeBool(v bool) {
+	if v {
+		e.b = append(e.b, 0xf5)
+	} else {
+		e.b = append(e.b, 0xf4)
+	}
+}
+
+func (e *cborEncoder) writeInt(v int64) {
+	if v >= 0 {
+		e.head(cborUint, uint64(v))
+	} else {
+		e.head(cborNegint, uint64(^v))
+	}
+}
+
+func (e *cborEncoder) writeUint(v uint64) {
+	e.head(cborUint, v)
+}
+
+func (e *cborEncoder) writeFloat32(v float32) {
+	e.b = binary.BigEndian.AppendUint32(append(e.b, 0xfa), math.Float32bits(v))
+}
+
+func (e *cborEncoder) writeFloat64(v float64) {
+	e.b = binary.BigEndian.AppendUint64(append(e.b, 0xfb), math.Float64bits(v))
+}
+
+func (e *cborEncoder) writeString(v string) {
+	e.head(cborText, uint64(len(v)))
+	e.b = append(e.b, v...)
+}
+
+func (e *cborEncoder) writeBytes(v []byte) {
+	e.head(cborBytes, uint64(len(v)))
+	e.b = append(e.b, v...)
+}
+
+func (e *cborEncoder) writeArray(n int) {
+	e.head(cborArray, uint64(n))
+}
+
+func (e *cborEncoder) writeMap(n int) {
+	e.head(cborMap, uint64(n))
+}
+
+// writeTime writes epoch time with tag 1, or RFC 3339 string with tag 0
+// if it has fraction of second, which float would not keep exactly.
+func (e *cborEncoder) writeTime(t time.Time) {
+	if t.Nanosecond() == 0 {
+		e.head(cborTag, 1)
+		e.writeInt(t.Unix())
+		return
+	}
+	e.head(cborTag, 0)
+	e.writeString(t.Format(time.RFC3339Nano))
+}
+
+// errBreak is returned by item for break stop code
+// of indefinite length items.
+var errBreak = errors.New("break")
+
+type cborDecoder struct {
+	b []byte
+}
+
+func (d *cborDecoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)) {
+		return nil, errTruncated
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b, nil
+}
+
+// head reads major type, additional information and argument.
+// Indefinite length has additional information 31.
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, err error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		b, err := d.next(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		switch len(b) {
+		case 1:
+			arg = uint64(b[0])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(b))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(b))
+		default:
+			arg = binary.BigEndian.Uint64(b)
+		}
+		return major, info, arg, nil
+	case info == 31:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("invalid additional information %d", info)
+}
+
+// value decodes data item, break stop code is an error.
+func (d *cborDecoder) value(depth int) (any, error) {
+	v, err := d.item(depth)
+	if err == errBreak {
+		return nil, errors.New("unexpected break")
+	}
+	return v, err
+}
+
+// item decodes data item or returns errBreak for break stop code.
+func (d *cborDecoder) item(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errMaxDepth
+	}
+
+	major, info, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	indefinite := info == 31
+
+	switch major {
+	case cborUint:
+		if indefinite {
+			break
+		}
+		if arg > math.MaxInt64 {
+			return arg, nil
+		}
+		return int64(arg), nil
+	case cborNegint:
+		if indefinite {
+			break
+		}
+		if arg > math.MaxInt64 {
+			return nil, errors.New("negative integer overflow")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		b, err := d.str(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+	case cborArray:
+		return d.array(arg, indefinite, depth)
+	case cborMap:
+		return d.mapValue(arg, indefinite, depth)
+	case cborTag:
+		if indefinite {
+			break
+		}
+		return d.tag(arg, depth)
+	case cborSimple:
+		return d.simple(info, arg)
+	}
+	return nil, fmt.Errorf("invalid indefinite length of major type %d", major)
+}
+
+// str reads byte or text string, indefinite string is concatenated
+// from definite chunks of the same major type.
+func (d *cborDecoder) str(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	}
+
+	var s []byte
+	for {
+		if len(d.b) > 0 && d.b[0] == 0xff {
+			d.b = d.b[1:]
+			return s, nil
+		}
+		m, info, n, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || info == 31 {
+			return nil, errors.New("invalid chunk of indefinite string")
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, b...)
+	}
+}
+
+func (d *cborDecoder) array(n uint64, indefinite bool, depth int) (any, error) {
+	if indefinite {
+		var a []any
+		for {
+			v, err := d.item(depth + 1)
+			if err == errBreak {
+				if a == nil {
+					a = []any{}
+				}
+				return a, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	}
+
+	if n > uint64(len(d.b)) {
+		return nil, errTruncated
+	}
+	a := make([]any, n)
+	for i := range a {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (d *cborDecoder) mapValue(n uint64, indefinite bool, depth int) (any, error) {
+	if !indefinite && n > uint64(len(d.b))/2 {
+		return nil, errTruncated
+	}
+
+	m := mapValue{}
+	for i := uint64(0); indefinite || i < n; i++ {
+		k, err := d.item(depth + 1)
+		if indefinite && err == errBreak {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, mapEntry{key: k, value: v})
+	}
+	return m, nil
+}
+
+// tag decodes date/time and bignum tags, other tags
+// are decoded as the tagged value.
+func (d *cborDecoder) tag(tag uint64, depth int) (any, error) {
+	v, err := d.value(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case 0:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("invalid date/time string")
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case 1:
+		t, err := toTime(v)
+		if err != nil {
+			return nil, errors.New("invalid epoch date/time")
+		}
+		return t, nil
+	case 2, 3:
+		b, ok := v.([]byte)
+		if !ok {
+			return nil, errors.New("invalid bignum")
+		}
+		n := new(big.Int).SetBytes(b)
+		if tag == 3 {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+		switch {
+		case n.IsInt64():
+			return n.Int64(), nil
+		case n.IsUint64():
+			return n.Uint64(), nil
+		}
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, nil
+	}
+	return v, nil
+}
+
+func (d *cborDecoder) simple(info byte, arg uint64) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return float16(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	case 31:
+		return nil, errBreak
+	}
+	return nil, fmt.Errorf("unsupported simple value %d", arg)
+}
+
+// float16 converts IEEE 754 half precision float.
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	frac := float64(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
@@ -0,0 +1,111 @@
+package restreq
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Examples of RFC 8949 Appendix A.
+func TestCBORDecode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"c249010000000000000000", 18446744073709551616.0},
+		{"3bffffffffffffffff", nil},
+		{"3863", int64(-100)},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c11a514b67b0", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"6449455446", "IETF"},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.in)
+		var got any
+		err := cborCodec{}.Unmarshal(b, &got)
+		if tt.want == nil && tt.in != "f6" {
+			if err == nil {
+				t.Errorf("decode %s = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decode %s = %#v, %v, want %#v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestCBORDecodeInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "18", "1f", "62", "5f01ff", "5f4101", "9f", "8201", "a1", "bf01ff", "ff",
+		"fc", "c0", "c001", "0001", "7f4101ff",
+	} {
+		b, _ := hex.DecodeString(s)
+		var v any
+		if err := (cborCodec{}).Unmarshal(b, &v); err == nil {
+			t.Errorf("decode %q = %#v, want error", s, v)
+		}
+	}
+}
+
+func TestCBOREncode(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{0, "00"},
+		{uint(24), "1818"},
+		{-1000, "3903e7"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"a", "6161"},
+		{[]byte{1}, "4101"},
+		{[]int{1, 2}, "820102"},
+		{map[string]int{"b": 2, "a": 1}, "a2616101616202"},
+		{time.Unix(1363896240, 0), "c11a514b67b0"},
+	}
+	for _, tt := range tests {
+		b, err := cborCodec{}.Marshal(tt.in)
+		if err != nil || hex.EncodeToString(b) != tt.want {
+			t.Errorf("encode %#v = %x, %v, want %s", tt.in, b, err, tt.want)
+		}
+	}
+}
+
+func FuzzCBORDecode(f *testing.F) {
+	for _, s := range []string{"a26161016162820203", "9f018202039f0405ffff", "c11a514b67b0", "c249010000000000000000", "f97bff"} {
+		b, _ := hex.DecodeString(s)
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var v any
+		if err := (cborCodec{}).Unmarshal(b, &v); err != nil {
+			return
+		}
+		if _, err := (cborCodec{}).Marshal(v); err != nil {
+			t.Fatalf("encode %#v decoded from %x: %v", v, b, err)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package restreq
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client keeps defaults shared by requests created with Client.New,
+// and a connection pool reused by them.
+type Client struct {
+	mu         sync.RWMutex
+	err        error
+	baseURL    string
+	headers    map[string]string
+	defaults   map[string]any
+	username   string
+	password   string
+	secretAuth *secretAuth
+	signer     Signer
+	bearer     TokenProvider
+	digest     *digestAuth
+	apiKey     *apiKey
+	timeout    time.Duration
+	httpClient *http.Client
+	vars       *Vars
+	stats      *latencyStats
+	auditSink  AuditSink
+	fixtureDir string
+	scrubber   *Scrubber
+	router     Router
+	envelope   *envelope
+	sniff      bool
+	profiling  bool
+	decode     DecodeOptions
+
+	contentDigest []string
+	verifyDigest  bool
+
+	rateLimiter  RateLimiter
+	hostLimiters map[string]RateLimiter
+
+	retryCount     int
+	retryWait      time.Duration
+	retryMaxWait   time.Duration
+	retryCondition func(*Response, error) bool
+	retryBackoff   Backoff
+	methodFallback map[string]string
+	errorFallback  func(*Request, error) (*Response, error)
+
+	beforeHooks    []func(*http.Request) error
+	afterHooks     []func(*Response) error
+	errorDetectors []ErrorDetector
+	onDeprecation  func(string, Deprecation)
+	invariants     []func(*Response) error
+	onViolation    func(string, error)
+
+	socket   socketOptions
+	quirks   []*hostQuirks
+	policies []endpointPolicy
+
+	tlsSession *tlsSession
+	pins       map[[sha256.Size]byte]bool
+	tokenCache TokenCache
+	inflight   inflight
+	http2Ping  *http2Ping
+
+	profiles map[string]*profile
+	profile  string
+}
+
+// NewClient creates client with base URL, which is joined
+// with paths passed to Client.New.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		headers: make(map[string]string),
+		httpClient: &http.Client{
+			Transport:     http.DefaultTransport.(*http.Transport).Clone(),
+			CheckRedirect: checkRedirect,
+		},
+		vars: NewVars(),
+	}
+}
+
+// SetDefaultHeader sets header added to every request.
+func (c *Client) SetDefaultHeader(k, v string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[k] = v
+	return c
+}
+
+// SetAuth sets basic auth used by every request.
+func (c *Client) SetAuth(username, password string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAuth()
+	c.username = username
+	c.password = password
+	return c
+}
+
+// SetTimeout sets default timeout of every request.
+func (c *Client) SetTimeout(t time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = t
+	return c
+}
+
+// SetTransport sets transport of HTTP client of the client,
+// e.g. restreqtest.Transport. Socket and TLS options apply
+// only to *http.Transport.
+func (c *Client) SetTransport(t http.RoundTripper) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient.Transport = t
+	c.resetProfiles()
+	return c
+}
+
+// SetBaseURL sets base URL joined with paths passed to Client.New.
+func (c *Client) SetBaseURL(u string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = u
+	return c
+}
+
+// Vars returns variable store shared by requests of the client and
+// enables its templates in requests created after the call.
+func (c *Client) Vars() *Vars {
+	c.vars.enable()
+	return c.vars
+}
+
+// New creates request to path relative to base URL, with defaults
+// of the client. Defaults can be overridden on the request.
+func (c *Client) New(path string) *Request {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r := New(joinURL(c.baseURL, path))
+	r.baseURL = c.baseURL
+	if c.err != nil {
+		r.setErr(c.err)
+	}
+	for k, v := range c.headers {
+		r.headers[k] = v
+	}
+	r.defaults = c.defaults
+	r.username = c.username
+	r.password = c.password
+	r.secretAuth = c.secretAuth
+	r.bearer = c.bearer
+	r.digest = c.digest
+	r.signer = c.signer
+	r.timeout = c.timeout
+	r.client = c.httpClient
+	if c.vars.enabled() {
+		r.vars = c.vars
+	}
+	r.clientVars = c.vars
+	r.stats = c.stats
+	r.tlsSession = c.tlsSession
+	r.inflight = &c.inflight
+	r.auditSink = c.auditSink
+	r.fixtureDir = c.fixtureDir
+	r.scrubber = c.scrubber
+	r.router = c.router
+	r.envelope = c.envelope
+	r.sniff = c.sniff
+	r.profiling = c.profiling
+	r.decode = c.decode
+	r.contentDigest = c.contentDigest
+	r.verifyDigest = c.verifyDigest
+	r.methodFallback = c.methodFallback
+	r.errorFallback = c.errorFallback
+	r.rateLimiter = c.limiter
+	r.quirks = c.hostQuirks
+	r.onDeprecation = c.onDeprecation
+	r.retryCount = c.retryCount
+	r.retryWait = c.retryWait
+	r.retryMaxWait = c.retryMaxWait
+	r.retryCondition = c.retryCondition
+	r.retryBackoff = c.retryBackoff
+	r.beforeHooks = append(r.beforeHooks, c.beforeHooks...)
+	r.afterHooks = append(r.afterHooks, c.afterHooks...)
+	r.errorDetectors = append(r.errorDetectors, c.errorDetectors...)
+	r.invariants = append(r.invariants, c.invariants...)
+	r.onViolation = c.onViolation
+	r.endpoint = path
+
+	if k := c.apiKey; k != nil {
+		r.SetAPIKey(k.name, k.value, k.in)
+	}
+
+	if p, ok := c.policy(path); ok {
+		p.apply(r)
+	}
+
+	if p, ok := c.profiles[c.profile]; ok {
+		p.apply(c, r, path)
+	}
+	return r
+}
+
+// joinURL joins base URL and path with exactly one slash.
+// Absolute URL in path is used as is.
+func joinURL(base, path string) string {
+	if base == "" || strings.Contains(path, "://") {
+		return path
+	}
+	if path == "" {
+		return base
+	}
+	if strings.HasPrefix(path, "?") || strings.HasPrefix(path, "#") {
+		return base + path
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
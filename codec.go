@@ -0,0 +1,149 @@
+package restreq
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Codec encodes and decodes payloads of a media type.
+type Codec interface {
+	// ContentType returns media type, e.g. application/msgpack.
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{
+	m: map[string]Codec{
+		"application/json":    jsonCodec{},
+		"application/xml":     xmlCodec{},
+		"application/msgpack": msgpackCodec{},
+		"application/cbor":    cborCodec{},
+	},
+}
+
+// codecAliases are media types handled by codec of other media type.
+var codecAliases = map[string]string{
+	"text/json":             "application/json",
+	"text/xml":              "application/xml",
+	"application/x-msgpack": "application/msgpack",
+	"application/protobuf":  "application/x-protobuf",
+}
+
+// RegisterCodec registers codec for SetPayload and Response.Decode.
+// JSON, XML, MessagePack and CBOR are registered by default.
+func RegisterCodec(c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[c.ContentType()] = c
+}
+
+// codecFor returns codec of media type. Parameters are ignored
+// and structured syntax suffixes like +json use the base codec.
+func codecFor(contentType string) (Codec, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	if a, ok := codecAliases[mt]; ok {
+		mt = a
+	}
+
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	if c, ok := codecs.m[mt]; ok {
+		return c, nil
+	}
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 {
+		if c, ok := codecs.m["application/"+mt[i+1:]]; ok {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no codec for content type %q", contentType)
+}
+
+// SetPayload encodes p with codec registered for contentType
+// and sets Content-Type. It replaces JSON body.
+// Encoding error is returned when the request is sent.
+func (r *Request) SetPayload(contentType string, p any) requester {
+	c, err := codecFor(contentType)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+
+	b, err := c.Marshal(p)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+
+	r.rawBody = b
+	r.headers["Content-Type"] = contentType
+	return r
+}
+
+// Decode decodes body with codec chosen by Content-Type of the response,
+// or by type of the body if sniffing is enabled.
+// JSON is decoded by DecodeJSON, with response envelope if set.
+// 204 and 205 responses and empty bodies are not decoded, see
+// ZeroOnEmpty and StrictEmpty of DecodeOptions.
+func (r *Response) Decode(v any) error {
+	if ok, err := r.empty(v); ok {
+		return err
+	}
+	c, err := codecFor(r.ContentType())
+	if err != nil {
+		return err
+	}
+	if _, ok := c.(jsonCodec); ok {
+		return r.DecodeJSON(v)
+	}
+	defer r.observeDecode(time.Now())
+	return c.Unmarshal(r.Body, v)
+}
+
+// setErr keeps the first error of setters, returned when
+// the request is sent.
+func (r *Request) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return marshalJSON(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	unmarshal, _ := jsonUnmarshal()
+	return unmarshal(data, v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v)
+}
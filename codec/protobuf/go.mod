@@ -0,0 +1,7 @@
+module github.com/scootpl/restreq/codec/protobuf
+
+go 1.19
+
+require github.com/scootpl/restreq v0.0.0-20261014133546-6fc808051d6e
+
+require google.golang.org/protobuf v1.31.0
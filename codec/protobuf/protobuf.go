@@ -0,0 +1,65 @@
+// Package protobuf registers Protocol Buffers codec for restreq.
+//
+//	import "github.com/scootpl/restreq/codec/protobuf"
+//
+//	req := restreq.New("http://example.com")
+//	protobuf.SetProtoPayload(req, msg)
+//	resp, err := req.Post()
+//	...
+//	err = protobuf.DecodeProto(resp, out)
+//
+// Importing the package registers Codec, so Response.Decode also
+// decodes application/x-protobuf and application/protobuf responses
+// into proto.Message values.
+package protobuf
+
+import (
+	"fmt"
+
+	"github.com/scootpl/restreq"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentType is media type of Protocol Buffers payloads.
+const ContentType = "application/x-protobuf"
+
+func init() {
+	restreq.RegisterCodec(Codec{})
+}
+
+// SetProtoPayload encodes m to request body and sets
+// Content-Type to application/x-protobuf.
+func SetProtoPayload(r *restreq.Request, m proto.Message) {
+	r.SetPayload(ContentType, m)
+}
+
+// DecodeProto decodes response body to m.
+func DecodeProto(r *restreq.Response, m proto.Message) error {
+	return proto.Unmarshal(r.Body, m)
+}
+
+// Codec encodes and decodes proto.Message values.
+type Codec struct{}
+
+// ContentType returns application/x-protobuf.
+func (Codec) ContentType() string {
+	return ContentType
+}
+
+// Marshal encodes v, which must be proto.Message.
+func (Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T is not proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes data to v, which must be proto.Message.
+func (Codec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf: %T is not proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
@@ -0,0 +1,187 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coerceTag is struct tag with coercion hint of the field.
+const coerceTag = "coerce"
+
+// coerceJSON rewrites JSON b with values of fields hinted by coerce
+// tags of t converted to types of the fields.
+func coerceJSON(b []byte, t reflect.Type, layouts []string) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	v, err := coerceValue(v, t, "", "", layouts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// coerceValue converts v decoded from JSON to be decoded to type t,
+// with hint and time layouts of the field.
+func coerceValue(v any, t reflect.Type, hint, layout string, layouts []string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := codecFields(t, "json")
+			for k, e := range x {
+				f, ok := findField(fields, k)
+				if !ok {
+					continue
+				}
+				sf := t.FieldByIndex(f.index)
+				e, err := coerceValue(e, sf.Type, sf.Tag.Get(coerceTag), sf.Tag.Get("layout"), layouts)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				x[k] = e
+			}
+		case reflect.Map:
+			for k, e := range x {
+				e, err := coerceValue(e, t.Elem(), hint, layout, layouts)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				x[k] = e
+			}
+		}
+		return x, nil
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, e := range x {
+				e, err := coerceValue(e, t.Elem(), hint, layout, layouts)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+				x[i] = e
+			}
+		}
+		return x, nil
+	}
+
+	switch hint {
+	case "number":
+		return coerceNumber(v)
+	case "bool":
+		return coerceBool(v)
+	case "time":
+		if t != timeType {
+			return v, nil
+		}
+		return coerceTime(v, layout, layouts)
+	case "string":
+		switch x := v.(type) {
+		case json.Number:
+			return string(x), nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		}
+	}
+	return v, nil
+}
+
+func coerceNumber(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return v, nil
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil || !json.Valid([]byte(s)) {
+		return nil, fmt.Errorf("cannot coerce %q to number", s)
+	}
+	return json.Number(s), nil
+}
+
+func coerceBool(v any) (any, error) {
+	switch x := v.(type) {
+	case json.Number:
+		switch x {
+		case "0":
+			return false, nil
+		case "1":
+			return true, nil
+		}
+		return nil, fmt.Errorf("cannot coerce %s to bool", x)
+	case string:
+		switch strings.ToLower(strings.TrimSpace(x)) {
+		case "1", "true", "yes", "on":
+			return true, nil
+		case "0", "false", "no", "off", "":
+			return false, nil
+		}
+		return nil, fmt.Errorf("cannot coerce %q to bool", x)
+	}
+	return v, nil
+}
+
+func coerceTime(v any, layout string, layouts []string) (any, error) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("cannot coerce %s to time", x)
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(time.RFC3339Nano), nil
+	case string:
+		var all []string
+		if layout != "" {
+			all = strings.Split(layout, "|")
+		}
+		all = append(append(all, layouts...), time.RFC3339)
+		for _, l := range all {
+			if t, err := time.Parse(l, x); err == nil {
+				return t.Format(time.RFC3339Nano), nil
+			}
+		}
+		return nil, fmt.Errorf("cannot coerce %q to time", x)
+	}
+	return v, nil
+}
+
+// hasCoerce reports if values of type t can have fields with
+// coerce tag.
+func hasCoerce(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	if seen == nil {
+		seen = map[reflect.Type]bool{}
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasCoerce(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if _, ok := f.Tag.Lookup(coerceTag); ok || hasCoerce(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
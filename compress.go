@@ -0,0 +1,72 @@
+package restreq
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compressor compresses request body.
+type Compressor interface {
+	// Encoding returns Content-Encoding value, e.g. gzip.
+	Encoding() string
+	// Compress returns writer compressing to w.
+	Compress(w io.Writer) (io.WriteCloser, error)
+}
+
+var compressors = struct {
+	sync.RWMutex
+	m map[string]Compressor
+}{
+	m: map[string]Compressor{
+		"gzip":    gzipCompressor{},
+		"deflate": deflateCompressor{},
+	},
+}
+
+// RegisterCompressor registers compressor for SetRequestCompression.
+// gzip and deflate are registered by default.
+func RegisterCompressor(c Compressor) {
+	compressors.Lock()
+	defer compressors.Unlock()
+	compressors.m[c.Encoding()] = c
+}
+
+func compressor(name string) (Compressor, error) {
+	compressors.RLock()
+	defer compressors.RUnlock()
+
+	c, ok := compressors.m[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression: %s", name)
+	}
+	return c, nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+type deflateCompressor struct{}
+
+func (deflateCompressor) Encoding() string {
+	return "deflate"
+}
+
+// Compress writes zlib format, which is meant by deflate
+// Content-Encoding (RFC 9110), not raw DEFLATE.
+func (deflateCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	zw, err := zlib.NewWriterLevel(w, zlib.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return zw, nil
+}
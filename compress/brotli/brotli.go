@@ -0,0 +1,38 @@
+// Package brotli registers brotli request compression for restreq.
+//
+//	import _ "github.com/scootpl/restreq/compress/brotli"
+//
+//	resp, err := restreq.New("http://example.com").
+//		SetRequestCompression("br").
+//		Post()
+//
+// Compression uses github.com/andybalholm/brotli, a pure Go port, so
+// no cgo is needed. Default quality is 6, register Compressor with
+// another Level to change it.
+package brotli
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/scootpl/restreq"
+)
+
+func init() {
+	restreq.RegisterCompressor(Compressor{Level: brotli.DefaultCompression})
+}
+
+// Compressor compresses with brotli at Level.
+type Compressor struct {
+	Level int
+}
+
+// Encoding returns br.
+func (Compressor) Encoding() string {
+	return "br"
+}
+
+// Compress returns writer compressing to w.
+func (c Compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return brotli.NewWriterLevel(w, c.Level), nil
+}
@@ -0,0 +1,7 @@
+module github.com/scootpl/restreq/compress/brotli
+
+go 1.19
+
+require github.com/scootpl/restreq v0.0.0-20261014133546-6fc808051d6e
+
+require github.com/andybalholm/brotli v1.0.6
@@ -0,0 +1,7 @@
+module github.com/scootpl/restreq/compress/zstd
+
+go 1.19
+
+require github.com/scootpl/restreq v0.0.0-20261014133546-6fc808051d6e
+
+require github.com/klauspost/compress v1.17.4
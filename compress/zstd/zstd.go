@@ -0,0 +1,37 @@
+// Package zstd registers zstd request compression for restreq.
+//
+//	import _ "github.com/scootpl/restreq/compress/zstd"
+//
+//	resp, err := restreq.New("http://example.com").
+//		SetRequestCompression("zstd").
+//		Post()
+//
+// Compression uses github.com/klauspost/compress/zstd at default speed.
+// Register Compressor with another Level to change it.
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/scootpl/restreq"
+)
+
+func init() {
+	restreq.RegisterCompressor(Compressor{Level: zstd.SpeedDefault})
+}
+
+// Compressor compresses with zstd at Level.
+type Compressor struct {
+	Level zstd.EncoderLevel
+}
+
+// Encoding returns zstd.
+func (Compressor) Encoding() string {
+	return "zstd"
+}
+
+// Compress returns writer compressing to w.
+func (c Compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.Level))
+}
@@ -0,0 +1,21 @@
+package restreq
+
+import (
+	"net/http"
+	"time"
+)
+
+// SetIfUnmodifiedSince sets If-Unmodified-Since header, so the server
+// rejects the update with 412 if the resource changed after t,
+// e.g. Last-Modified of a prior GET.
+func (r *Request) SetIfUnmodifiedSince(t time.Time) requester {
+	r.headers["If-Unmodified-Since"] = t.UTC().Format(http.TimeFormat)
+	return r
+}
+
+// LastModified returns time from Last-Modified header.
+// ok is false if the header is missing or invalid.
+func (r *Response) LastModified() (t time.Time, ok bool) {
+	t, err := http.ParseTime(r.Header("Last-Modified"))
+	return t, err == nil
+}
@@ -0,0 +1,54 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+)
+
+// CredentialStore returns secrets by name, e.g. from OS keychain
+// or encrypted file. Secrets are fetched when the request is built
+// and are not kept by the request.
+type CredentialStore interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// CredentialStoreFunc adapts function to CredentialStore.
+type CredentialStoreFunc func(ctx context.Context, name string) (string, error)
+
+// GetSecret calls f.
+func (f CredentialStoreFunc) GetSecret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+type secretAuth struct {
+	store    CredentialStore
+	username string
+	name     string
+}
+
+// SetBasicAuthSecret sets basic auth with username and password
+// fetched from store by name, every time the request is sent.
+func (r *Request) SetBasicAuthSecret(store CredentialStore, username, name string) requester {
+	r.clearAuth()
+	r.secretAuth = &secretAuth{store: store, username: username, name: name}
+	return r
+}
+
+// SetAuthSecret sets basic auth of every request, with password
+// fetched from store by name.
+func (c *Client) SetAuthSecret(store CredentialStore, username, name string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAuth()
+	c.secretAuth = &secretAuth{store: store, username: username, name: name}
+	return c
+}
+
+// password fetches password from the store.
+func (a *secretAuth) password(ctx context.Context) (string, error) {
+	p, err := a.store.GetSecret(ctx, a.name)
+	if err != nil {
+		return "", fmt.Errorf("credential %q: %w", a.name, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,67 @@
+// Package agefile reads restreq credentials from age encrypted file.
+//
+// The file holds JSON object of secrets by name, encrypted with age:
+//
+//	echo '{"api":"s3cr3t"}' | age -r age1... > secrets.age
+//
+//	ids, err := age.ParseIdentities(keyFile)
+//	store := agefile.New("secrets.age", ids...)
+//	resp, err := restreq.New("http://example.com").
+//		SetBasicAuthSecret(store, "alice", "api").
+//		Get()
+//
+// The file is decrypted on every lookup, so secrets are not kept
+// in memory and edits of the file apply to the next request.
+package agefile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+	"github.com/scootpl/restreq"
+)
+
+// Store reads secrets from Path, decrypted with Identities.
+type Store struct {
+	Path       string
+	Identities []age.Identity
+}
+
+var _ restreq.CredentialStore = (*Store)(nil)
+
+// New creates store of file at path.
+func New(path string, identities ...age.Identity) *Store {
+	return &Store{Path: path, Identities: identities}
+}
+
+// GetSecret decrypts the file and returns secret name.
+func (s *Store) GetSecret(ctx context.Context, name string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("agefile: %w", err)
+	}
+	defer f.Close()
+
+	r, err := age.Decrypt(f, s.Identities...)
+	if err != nil {
+		return "", fmt.Errorf("agefile: %s: %w", s.Path, err)
+	}
+
+	var secrets map[string]string
+	if err := json.NewDecoder(r).Decode(&secrets); err != nil {
+		return "", fmt.Errorf("agefile: %s: %w", s.Path, err)
+	}
+
+	v, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("agefile: %s: secret %q not found", s.Path, name)
+	}
+	return v, nil
+}
@@ -0,0 +1,12 @@
+module github.com/scootpl/restreq/credential/agefile
+
+go 1.19
+
+require github.com/scootpl/restreq v0.0.0-20261014133546-6fc808051d6e
+
+require filippo.io/age v1.1.1
+
+require (
+	golang.org/x/crypto v0.4.0 // indirect
+	golang.org/x/sys v0.3.0 // indirect
+)
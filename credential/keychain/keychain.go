@@ -0,0 +1,65 @@
+// Package keychain reads restreq credentials from OS keychain.
+//
+//	store := keychain.New("my-cli")
+//	resp, err := restreq.New("http://example.com").
+//		SetBasicAuthSecret(store, "alice", "api").
+//		Get()
+//
+// macOS Keychain is read with security, Secret Service on Linux
+// and BSD with secret-tool of libsecret.
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/scootpl/restreq"
+)
+
+// ErrUnsupported is returned on systems without supported keychain.
+var ErrUnsupported = errors.New("keychain: unsupported system")
+
+// Store reads generic passwords of Service, with account set
+// to name of the secret.
+type Store struct {
+	Service string
+}
+
+var _ restreq.CredentialStore = (*Store)(nil)
+
+// New creates store of service.
+func New(service string) *Store {
+	return &Store{Service: service}
+}
+
+// GetSecret returns password of account name.
+func (s *Store) GetSecret(ctx context.Context, name string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", s.Service, "-a", name, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", s.Service, "account", name)
+	default:
+		return "", ErrUnsupported
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("keychain: %s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("keychain: %s: %w", name, err)
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("keychain: %s: not found", name)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
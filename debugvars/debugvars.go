@@ -0,0 +1,88 @@
+/*
+Package debugvars exposes statistics of a restreq.Client for debugging
+live services, as expvar variable or JSON handler mounted on existing mux.
+
+	c := restreq.NewClient("https://api.example.com").EnableStats(0)
+
+	debugvars.Publish("api", c)
+	debugvars.Mount(mux, "/debug/restreq", c)
+
+It is a separate package, because importing expvar registers
+/debug/vars on http.DefaultServeMux.
+*/
+package debugvars
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sort"
+
+	"github.com/scootpl/restreq"
+)
+
+// Endpoint is a snapshot of endpoint statistics.
+// Latencies are in milliseconds.
+type Endpoint struct {
+	Endpoint   string  `json:"endpoint"`
+	Requests   int64   `json:"requests"`
+	Errors     int64   `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	Retries    int64   `json:"retries"`
+	Violations int64   `json:"violations"`
+	P50        float64 `json:"p50_ms"`
+	P95        float64 `json:"p95_ms"`
+	P99        float64 `json:"p99_ms"`
+}
+
+// Snapshot returns statistics of endpoints of c sorted by name.
+func Snapshot(c *restreq.Client) []Endpoint {
+	st := c.Stats()
+
+	names := make([]string, 0, len(st.Counters))
+	for k := range st.Counters {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	endpoints := make([]Endpoint, 0, len(names))
+	for _, k := range names {
+		n := st.Counters[k]
+		l := st.Endpoints[k]
+		endpoints = append(endpoints, Endpoint{
+			Endpoint:   k,
+			Requests:   n.Requests,
+			Errors:     n.Errors,
+			ErrorRate:  n.ErrorRate(),
+			Retries:    n.Retries,
+			Violations: n.Violations,
+			P50:        float64(l.P50.Microseconds()) / 1000,
+			P95:        float64(l.P95.Microseconds()) / 1000,
+			P99:        float64(l.P99.Microseconds()) / 1000,
+		})
+	}
+	return endpoints
+}
+
+// Publish publishes statistics of c as expvar variable.
+// Like expvar.Publish, it panics if the name is already registered.
+func Publish(name string, c *restreq.Client) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return Snapshot(c)
+	}))
+}
+
+// Handler returns handler serving statistics of c as JSON.
+func Handler(c *restreq.Client) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(Snapshot(c))
+	})
+}
+
+// Mount registers Handler of c on mux with pattern.
+func Mount(mux *http.ServeMux, pattern string, c *restreq.Client) {
+	mux.Handle(pattern, Handler(c))
+}
@@ -0,0 +1,86 @@
+package debugvars
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scootpl/restreq"
+)
+
+func testClient(t *testing.T) *restreq.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := restreq.NewClient(srv.URL).EnableStats(0)
+	for _, p := range []string{"/items", "/items", "/fail"} {
+		if _, err := c.New(p).Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+func checkEndpoints(t *testing.T, got []Endpoint) {
+	t.Helper()
+	want := []Endpoint{
+		{Endpoint: "GET /fail", Requests: 1, Errors: 1, ErrorRate: 1},
+		{Endpoint: "GET /items", Requests: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("endpoints %+v", got)
+	}
+	for i, e := range got {
+		w := want[i]
+		if e.Endpoint != w.Endpoint || e.Requests != w.Requests || e.Errors != w.Errors ||
+			e.ErrorRate != w.ErrorRate || e.Retries != 0 || e.P50 <= 0 || e.P99 < e.P50 {
+			t.Errorf("endpoint %+v, want %+v", e, w)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	c := testClient(t)
+	Publish("restreq_test", c)
+
+	v := expvar.Get("restreq_test")
+	if v == nil {
+		t.Fatal("variable not published")
+	}
+	var got []Endpoint
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	checkEndpoints(t, got)
+
+	var keys []map[string]any
+	json.Unmarshal([]byte(v.String()), &keys)
+	for _, k := range []string{"endpoint", "requests", "errors", "error_rate", "retries", "violations", "p50_ms", "p95_ms", "p99_ms"} {
+		if _, ok := keys[0][k]; !ok {
+			t.Errorf("key %s missing in %v", k, keys[0])
+		}
+	}
+}
+
+func TestMount(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/debug/restreq", testClient(t))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/debug/restreq", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+	var got []Endpoint
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	checkEndpoints(t, got)
+}
@@ -0,0 +1,279 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// DecodeOptions are options of JSON decoding of responses.
+// They work only with encoding/json, not with codec set by
+// SetJSONCodec or UseCodec, except TimeLayouts and Coerce.
+type DecodeOptions struct {
+	// UseNumber keeps numbers in interface values as json.Number.
+	UseNumber bool
+	// DisallowUnknownFields makes object keys without matching
+	// struct field an error.
+	DisallowUnknownFields bool
+	// TimeLayouts are layouts of Time values, tried in order.
+	// RFC 3339 is used if empty.
+	TimeLayouts []string
+	// Coerce converts values with wrong JSON type to type of struct
+	// fields with coerce tag:
+	//
+	//	Count   int       `json:"count" coerce:"number"`
+	//	Active  bool      `json:"active" coerce:"bool"`
+	//	Created time.Time `json:"created" coerce:"time" layout:"2006-01-02|02.01.2006"`
+	//	ID      string    `json:"id" coerce:"string"`
+	//
+	// number accepts numbers in strings, empty string is null. bool
+	// accepts 0, 1 and strings "0", "1", "true", "false", "yes", "no",
+	// "on", "off" and empty string. time accepts time.Time in layouts
+	// of layout tag separated by |, then TimeLayouts, then RFC 3339,
+	// and numbers as Unix seconds. string accepts numbers and booleans.
+	// Hints of slice, array and map fields apply to their elements.
+	Coerce bool
+	// ZeroOnEmpty sets the target to zero value when the response
+	// is 204, 205 or has empty body, otherwise it is left unchanged.
+	ZeroOnEmpty bool
+	// StrictEmpty makes decoding of such response fail with
+	// ErrEmptyBody.
+	StrictEmpty bool
+}
+
+// ErrEmptyBody is returned by decoding of 204 and 205 responses
+// and of empty bodies with StrictEmpty of DecodeOptions.
+var ErrEmptyBody = errors.New("restreq: empty response body")
+
+// UseNumber makes JSON decoding of the response keep numbers in
+// interface values as json.Number instead of float64, so large
+// integers like 64-bit IDs do not lose precision.
+func (r *Request) UseNumber() requester {
+	r.decode.UseNumber = true
+	return r
+}
+
+// UseNumber makes JSON decoding of every response use json.Number.
+func (c *Client) UseNumber() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decode.UseNumber = true
+	return c
+}
+
+// SetDecodeOptions sets options of JSON decoding of the response,
+// replacing options of the client.
+func (r *Request) SetDecodeOptions(o DecodeOptions) requester {
+	r.decode = o
+	return r
+}
+
+// SetDecodeOptions sets options of JSON decoding of every response.
+func (c *Client) SetDecodeOptions(o DecodeOptions) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decode = o
+	return c
+}
+
+// UseCodec replaces JSON codec for the request. It encodes
+// JSON body and decodes JSON response, e.g. with lenient parser
+// for one endpoint. It must be set before SetJSONPayload.
+func (r *Request) UseCodec(c Codec) requester {
+	r.codec = c
+	return r
+}
+
+// empty reports if the response is 204, 205 or has empty body,
+// which is not decoded. v is set to zero with ZeroOnEmpty.
+func (r *Response) empty(v any) (bool, error) {
+	if r.StatusCode != http.StatusNoContent && r.StatusCode != http.StatusResetContent &&
+		(r.streamed || len(bytes.TrimSpace(r.Body)) > 0) {
+		return false, nil
+	}
+	if r.decode.StrictEmpty {
+		return true, ErrEmptyBody
+	}
+	if rv := reflect.ValueOf(v); r.decode.ZeroOnEmpty && rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	}
+	return true, nil
+}
+
+// marshalJSON encodes JSON with codec of the request.
+func (r *Request) marshalJSON(v any) ([]byte, error) {
+	if r.codec != nil {
+		return r.codec.Marshal(v)
+	}
+	return marshalJSON(v)
+}
+
+// unmarshalJSON decodes JSON with options of the response.
+func (r *Response) unmarshalJSON(b []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	for (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if r.decode.Coerce && rv.IsValid() && hasCoerce(rv.Type(), nil) {
+		var err error
+		if b, err = coerceJSON(b, rv.Type(), r.decode.TimeLayouts); err != nil {
+			return err
+		}
+	}
+
+	if err := r.decodeJSON(b, v); err != nil {
+		return err
+	}
+
+	if !rv.IsValid() || !hasTime(rv.Type(), nil) {
+		return nil
+	}
+	return parseTimes(rv, r.decode.TimeLayouts)
+}
+
+func (r *Response) decodeJSON(b []byte, v any) error {
+	if r.codec != nil {
+		return r.codec.Unmarshal(b, v)
+	}
+
+	unmarshal, custom := jsonUnmarshal()
+	if custom || !r.decode.UseNumber && !r.decode.DisallowUnknownFields {
+		return unmarshal(b, v)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	if r.decode.UseNumber {
+		d.UseNumber()
+	}
+	if r.decode.DisallowUnknownFields {
+		d.DisallowUnknownFields()
+	}
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level JSON value")
+	}
+	return nil
+}
+
+// Time is time.Time decoded from JSON string with TimeLayouts
+// of DecodeOptions. Outside of restreq decoding, only RFC 3339
+// is parsed and other strings leave it zero.
+type Time struct {
+	time.Time
+	raw string
+}
+
+// UnmarshalJSON keeps the string, which is parsed after decoding.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("time must be string: %w", err)
+	}
+	t.raw = s
+	t.Time, _ = time.Parse(time.RFC3339, s)
+	return nil
+}
+
+var jsonTimeType = reflect.TypeOf(Time{})
+
+// parseTimes parses Time values in v with layouts.
+func parseTimes(v reflect.Value, layouts []string) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return parseTimes(v.Elem(), layouts)
+	case reflect.Struct:
+		if v.Type() == jsonTimeType {
+			if !v.CanAddr() {
+				return nil
+			}
+			return v.Addr().Interface().(*Time).parse(layouts)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := parseTimes(v.Field(i), layouts); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := parseTimes(v.Index(i), layouts); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !hasTime(v.Type().Elem(), nil) {
+			return nil
+		}
+		it := v.MapRange()
+		for it.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(it.Value())
+			if err := parseTimes(e, layouts); err != nil {
+				return err
+			}
+			v.SetMapIndex(it.Key(), e)
+		}
+	}
+	return nil
+}
+
+// hasTime reports if values of type t can contain Time.
+// Interfaces are skipped, JSON decodes them to maps and slices.
+func hasTime(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == jsonTimeType {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	if seen == nil {
+		seen = map[reflect.Type]bool{}
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasTime(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasTime(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *Time) parse(layouts []string) error {
+	if t.raw == "" {
+		return nil
+	}
+	s := t.raw
+	t.raw = ""
+
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339}
+	}
+	for _, l := range layouts {
+		if p, err := time.Parse(l, s); err == nil {
+			t.Time = p
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as time", s)
+}
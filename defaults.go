@@ -0,0 +1,100 @@
+package restreq
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetJSONDefault sets default value of JSON body field key of every
+// request. It is added to body of AddJSONKeyValue without key, set in
+// struct passed to SetJSONPayload if field named key is zero, and added
+// to map passed to SetJSONPayload without key. Structs without field
+// named key are left as they are.
+func (c *Client) SetJSONDefault(key string, value any) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.defaults == nil {
+		c.defaults = make(map[string]any)
+	}
+	c.defaults[key] = value
+	return c
+}
+
+// withDefaults returns copy of payload p with JSON defaults.
+func (r *Request) withDefaults(p any) (any, error) {
+	if len(r.defaults) == 0 || p == nil {
+		return p, nil
+	}
+
+	v := reflect.ValueOf(p)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return p, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		c := reflect.New(v.Type())
+		c.Elem().Set(v)
+		fields := codecFields(v.Type(), "json")
+		for k, d := range r.defaults {
+			f, ok := findField(fields, k)
+			if !ok {
+				continue
+			}
+			fv := c.Elem().FieldByIndex(f.index)
+			if !fv.IsZero() {
+				continue
+			}
+			if err := setDefault(fv, k, d); err != nil {
+				return nil, err
+			}
+		}
+		return c.Interface(), nil
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return p, nil
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len()+len(r.defaults))
+		it := v.MapRange()
+		for it.Next() {
+			c.SetMapIndex(it.Key(), it.Value())
+		}
+		for k, d := range r.defaults {
+			key := reflect.ValueOf(k).Convert(v.Type().Key())
+			if c.MapIndex(key).IsValid() {
+				continue
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := setDefault(e, k, d); err != nil {
+				return nil, err
+			}
+			c.SetMapIndex(key, e)
+		}
+		return c.Interface(), nil
+	}
+	return p, nil
+}
+
+// setDefault sets v to default d of key. Numbers are converted
+// to numeric type of v.
+func setDefault(v reflect.Value, key string, d any) error {
+	dv := reflect.ValueOf(d)
+	switch {
+	case !dv.IsValid():
+	case dv.Type().AssignableTo(v.Type()):
+		v.Set(dv)
+	case isNumber(dv.Kind()) && isNumber(v.Kind()):
+		v.Set(dv.Convert(v.Type()))
+	default:
+		return fmt.Errorf("json default %q: cannot use %T as %s", key, d, v.Type())
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Float64
+}
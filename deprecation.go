@@ -0,0 +1,139 @@
+package restreq
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deprecation is parsed from Deprecation, Sunset and
+// Link rel="deprecation" response headers.
+type Deprecation struct {
+	// Deprecated is true if the server sent any of the headers.
+	Deprecated bool
+	// Date when the resource was or will be deprecated, if known.
+	Date time.Time
+	// Sunset is date when the resource will stop responding, if known.
+	Sunset time.Time
+	// Link points to deprecation documentation.
+	Link string
+}
+
+// Deprecation returns deprecation info of the response.
+// Deprecation header in both RFC 9745 (@1688169599) and legacy
+// (true or HTTP-date) forms is supported.
+func (r *Response) Deprecation() Deprecation {
+	d := Deprecation{}
+
+	if v := strings.TrimSpace(r.Header("Deprecation")); v != "" {
+		d.Deprecated = true
+		if strings.HasPrefix(v, "@") {
+			if sec, err := strconv.ParseInt(v[1:], 10, 64); err == nil {
+				d.Date = time.Unix(sec, 0).UTC()
+			}
+		} else if t, err := http.ParseTime(v); err == nil {
+			d.Date = t
+		}
+	}
+
+	if v := r.Header("Sunset"); v != "" {
+		d.Deprecated = true
+		if t, err := http.ParseTime(v); err == nil {
+			d.Sunset = t
+		}
+	}
+
+	for _, l := range parseLinks(r.Response.Header.Values("Link")) {
+		if l.rel == "deprecation" {
+			d.Deprecated = true
+			d.Link = l.url
+		}
+	}
+
+	return d
+}
+
+// OnDeprecation sets callback invoked when the response reports
+// deprecation. endpoint is method and URL without query.
+func (r *Request) OnDeprecation(f func(endpoint string, d Deprecation)) requester {
+	r.onDeprecation = f
+	return r
+}
+
+// OnDeprecation sets callback invoked when response of any request
+// reports deprecation.
+func (c *Client) OnDeprecation(f func(endpoint string, d Deprecation)) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDeprecation = f
+	return c
+}
+
+// LogDeprecationOnce returns OnDeprecation callback, which logs
+// every deprecated endpoint once.
+func LogDeprecationOnce(l *log.Logger) func(string, Deprecation) {
+	var seen sync.Map
+
+	return func(endpoint string, d Deprecation) {
+		if _, loaded := seen.LoadOrStore(endpoint, true); loaded {
+			return
+		}
+
+		s := "Deprecated: " + endpoint
+		if !d.Sunset.IsZero() {
+			s += ", sunset " + d.Sunset.Format(time.RFC3339)
+		}
+		if d.Link != "" {
+			s += ", see " + d.Link
+		}
+		l.Println(s)
+	}
+}
+
+func (r *Request) checkDeprecation(req *http.Request, resp *Response) {
+	d := resp.Deprecation()
+	if !d.Deprecated {
+		return
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	r.onDeprecation(req.Method+" "+u.String(), d)
+}
+
+type link struct {
+	url string
+	rel string
+}
+
+// parseLinks parses Link header values like
+// <https://example.com/doc>; rel="deprecation".
+func parseLinks(values []string) []link {
+	var links []link
+
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if !strings.HasPrefix(part, "<") {
+				continue
+			}
+			end := strings.IndexByte(part, '>')
+			if end < 0 {
+				continue
+			}
+
+			l := link{url: part[1:end]}
+			for _, p := range strings.Split(part[end+1:], ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+				if ok && strings.EqualFold(k, "rel") {
+					l.rel = strings.ToLower(strings.Trim(v, `"`))
+				}
+			}
+			links = append(links, l)
+		}
+	}
+	return links
+}
@@ -0,0 +1,136 @@
+package restreq
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// Digest algorithms of RFC 9530.
+const (
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+)
+
+// ErrDigestMismatch is returned when digest of response body
+// does not match Content-Digest or Repr-Digest header.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+var digestHashes = map[string]func() hash.Hash{
+	DigestSHA256: sha256.New,
+	DigestSHA512: sha512.New,
+}
+
+// SetContentDigest adds Content-Digest header (RFC 9530) with digests
+// of the body as sent, after compression. SHA-256 is used if no
+// algorithm is given. Body must not be streamed.
+func (r *Request) SetContentDigest(algs ...string) requester {
+	r.contentDigest = digestAlgs(algs)
+	return r
+}
+
+// SetReprDigest adds Repr-Digest header (RFC 9530) with digests of
+// the body. For requests the representation is the body as sent.
+func (r *Request) SetReprDigest(algs ...string) requester {
+	r.reprDigest = digestAlgs(algs)
+	return r
+}
+
+// VerifyDigest makes the request verify digests of the response,
+// see Response.VerifyDigest. Mismatch is returned as error
+// wrapping ErrDigestMismatch, together with the response.
+func (r *Request) VerifyDigest() requester {
+	r.verifyDigest = true
+	return r
+}
+
+// SetContentDigest adds Content-Digest header to every request.
+func (c *Client) SetContentDigest(algs ...string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contentDigest = digestAlgs(algs)
+	return c
+}
+
+// VerifyDigest makes every request verify digests of the response.
+func (c *Client) VerifyDigest() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verifyDigest = true
+	return c
+}
+
+func digestAlgs(algs []string) []string {
+	if len(algs) == 0 {
+		return []string{DigestSHA256}
+	}
+	return algs
+}
+
+// VerifyDigest checks Content-Digest and Repr-Digest headers against
+// the body. Algorithms other than sha-256 and sha-512 are ignored.
+// Repr-Digest of partial content, and digests of bodies decompressed
+// by transport or streamed with WithBodyReader can't be verified
+// and are skipped.
+func (r *Response) VerifyDigest() error {
+	if r.streamed || r.Response.Uncompressed {
+		return nil
+	}
+
+	if err := verifyDigest("Content-Digest", r.Header("Content-Digest"), r.Body); err != nil {
+		return err
+	}
+	if r.StatusCode == http.StatusPartialContent {
+		return nil
+	}
+	return verifyDigest("Repr-Digest", r.Header("Repr-Digest"), r.Body)
+}
+
+func verifyDigest(header, v string, body []byte) error {
+	for alg, want := range parseDigest(v) {
+		h, ok := digestHashes[alg]
+		if !ok {
+			continue
+		}
+		d := h()
+		d.Write(body)
+		if !bytes.Equal(d.Sum(nil), want) {
+			return fmt.Errorf("%s %s: %w", header, alg, ErrDigestMismatch)
+		}
+	}
+	return nil
+}
+
+// digestHeader returns digest header value of body.
+func digestHeader(algs []string, body []byte) (string, error) {
+	s := make([]string, len(algs))
+	for i, alg := range algs {
+		h, ok := digestHashes[alg]
+		if !ok {
+			return "", fmt.Errorf("unsupported digest algorithm %q", alg)
+		}
+		d := h()
+		d.Write(body)
+		s[i] = alg + "=:" + base64.StdEncoding.EncodeToString(d.Sum(nil)) + ":"
+	}
+	return strings.Join(s, ", "), nil
+}
+
+// parseDigest parses dictionary of byte sequences like
+// sha-256=:base64:, skipping other members.
+func parseDigest(v string) map[string][]byte {
+	m := map[string][]byte{}
+	d, _ := ParseSFDict(v)
+	for _, member := range d {
+		if b, ok := member.Item.Value.([]byte); ok {
+			m[member.Key] = b
+		}
+	}
+	return m
+}
@@ -0,0 +1,235 @@
+package restreq
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// digestAuth answers Digest challenges (RFC 7616). The last challenge
+// is kept, so following requests are authorized without round trip.
+type digestAuth struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge map[string]string
+	nc        int
+}
+
+// SetDigestAuth sets Digest auth with username and password. Request
+// failed with 401 and Digest challenge is sent again with credentials.
+// MD5 and SHA-256 algorithms with qop auth and auth-int are supported.
+func (r *Request) SetDigestAuth(username, password string) requester {
+	r.clearAuth()
+	r.digest = &digestAuth{username: username, password: password}
+	return r
+}
+
+// SetDigestAuth sets Digest auth of every request. The challenge
+// is shared by requests of the client.
+func (c *Client) SetDigestAuth(username, password string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearAuth()
+	c.digest = &digestAuth{username: username, password: password}
+	return c
+}
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"MD5":          md5.New,
+	"MD5-SESS":     md5.New,
+	"SHA-256":      sha256.New,
+	"SHA-256-SESS": sha256.New,
+}
+
+// challenged reports if resp is 401 with usable Digest challenge, which
+// is kept for the next attempt. Challenge to request already sent with
+// credentials is used only if the nonce is stale.
+func (d *digestAuth) challenged(resp *Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	var best map[string]string
+	for _, v := range resp.Response.Header.Values("WWW-Authenticate") {
+		for _, c := range parseChallenges(v) {
+			if c["scheme"] != "digest" || c["nonce"] == "" {
+				continue
+			}
+			alg := strings.ToUpper(c["algorithm"])
+			if alg == "" {
+				alg = "MD5"
+			}
+			if digestAlgorithms[alg] == nil {
+				continue
+			}
+			c["algorithm"] = alg
+			if best == nil || strings.HasPrefix(alg, "SHA-256") && !strings.HasPrefix(best["algorithm"], "SHA-256") {
+				best = c
+			}
+		}
+	}
+	if best == nil {
+		return false
+	}
+
+	if req := resp.Response.Request; req != nil && strings.HasPrefix(req.Header.Get("Authorization"), "Digest ") &&
+		!strings.EqualFold(best["stale"], "true") {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.challenge = best
+	d.nc = 0
+	return true
+}
+
+// authorize sets Authorization header of req, if there is a challenge.
+func (d *digestAuth) authorize(req *http.Request) error {
+	d.mu.Lock()
+	c := d.challenge
+	if c == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	d.nc++
+	nc := fmt.Sprintf("%08x", d.nc)
+	d.mu.Unlock()
+
+	h := digestAlgorithms[c["algorithm"]]
+	H := func(s string) string {
+		d := h()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	cnonce := hex.EncodeToString(b)
+
+	qop := ""
+	for _, q := range strings.Split(c["qop"], ",") {
+		q = strings.TrimSpace(q)
+		if q == "auth" || q == "auth-int" && qop == "" {
+			qop = q
+		}
+	}
+
+	uri := req.URL.RequestURI()
+	ha1 := H(d.username + ":" + c["realm"] + ":" + d.password)
+	if strings.HasSuffix(c["algorithm"], "-SESS") {
+		ha1 = H(ha1 + ":" + c["nonce"] + ":" + cnonce)
+	}
+	ha2 := H(req.Method + ":" + uri)
+	if qop == "auth-int" {
+		body, err := requestBody(req)
+		if err != nil {
+			return fmt.Errorf("digest auth: %w", err)
+		}
+		ha2 = H(req.Method + ":" + uri + ":" + H(string(body)))
+	}
+
+	var response string
+	if qop == "" {
+		response = H(ha1 + ":" + c["nonce"] + ":" + ha2)
+	} else {
+		response = H(ha1 + ":" + c["nonce"] + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	s := []string{
+		"username=" + quoteDigest(d.username),
+		"realm=" + quoteDigest(c["realm"]),
+		"nonce=" + quoteDigest(c["nonce"]),
+		"uri=" + quoteDigest(uri),
+		"algorithm=" + c["algorithm"],
+		"response=" + quoteDigest(response),
+	}
+	if c["opaque"] != "" {
+		s = append(s, "opaque="+quoteDigest(c["opaque"]))
+	}
+	if qop != "" {
+		s = append(s, "qop="+qop, "nc="+nc, "cnonce="+quoteDigest(cnonce))
+	}
+	req.Header.Set("Authorization", "Digest "+strings.Join(s, ", "))
+	return nil
+}
+
+// parseChallenges parses WWW-Authenticate value to challenges with
+// lower case parameter names and scheme under key scheme.
+func parseChallenges(v string) []map[string]string {
+	var challenges []map[string]string
+	var cur map[string]string
+
+	for {
+		v = strings.TrimLeft(v, " \t,")
+		if v == "" {
+			return challenges
+		}
+
+		i := strings.IndexAny(v, " \t,=")
+		if i < 0 {
+			i = len(v)
+		}
+		tok := v[:i]
+		v = strings.TrimLeft(v[i:], " \t")
+
+		if !strings.HasPrefix(v, "=") {
+			cur = map[string]string{"scheme": strings.ToLower(tok)}
+			challenges = append(challenges, cur)
+			continue
+		}
+
+		v = strings.TrimLeft(v[1:], " \t")
+		var val string
+		if strings.HasPrefix(v, `"`) {
+			var ok bool
+			if val, v, ok = unquoteDigest(v); !ok {
+				return challenges
+			}
+		} else {
+			i := strings.IndexAny(v, " \t,")
+			if i < 0 {
+				i = len(v)
+			}
+			val, v = v[:i], v[i:]
+		}
+		if cur != nil {
+			cur[strings.ToLower(tok)] = val
+		}
+	}
+}
+
+// quoteDigest returns s as quoted-string.
+func quoteDigest(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// unquoteDigest reads quoted-string from start of s and returns
+// its value and the rest of s.
+func unquoteDigest(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", false
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return "", "", false
+}
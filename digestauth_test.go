@@ -0,0 +1,61 @@
+package restreq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChallenges(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []map[string]string
+	}{
+		{
+			`Digest realm="a b", nonce="n", qop="auth,auth-int"`,
+			[]map[string]string{{"scheme": "digest", "realm": "a b", "nonce": "n", "qop": "auth,auth-int"}},
+		},
+		{
+			`Basic realm="x", Digest Nonce=abc, algorithm=SHA-256`,
+			[]map[string]string{{"scheme": "basic", "realm": "x"}, {"scheme": "digest", "nonce": "abc", "algorithm": "SHA-256"}},
+		},
+		{
+			`Digest realm="a\"b\\c, d", nonce = "n"`,
+			[]map[string]string{{"scheme": "digest", "realm": `a"b\c, d`, "nonce": "n"}},
+		},
+		{
+			`realm="orphan", Bearer`,
+			[]map[string]string{{"scheme": "bearer"}},
+		},
+		{
+			`Digest nonce="unterminated`,
+			[]map[string]string{{"scheme": "digest"}},
+		},
+		{``, nil},
+	}
+	for _, tt := range tests {
+		if got := parseChallenges(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChallenges(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteDigest(t *testing.T) {
+	for _, s := range []string{"", "plain", `a"b`, `a\b`, `\"`} {
+		v, rest, ok := unquoteDigest(quoteDigest(s) + ", x")
+		if !ok || v != s || rest != ", x" {
+			t.Errorf("unquoteDigest(quoteDigest(%q)) = %q, %q, %v", s, v, rest, ok)
+		}
+	}
+}
+
+func FuzzParseChallenges(f *testing.F) {
+	f.Add(`Digest realm="r", nonce="n", qop="auth", algorithm=MD5, opaque="o"`)
+	f.Add(`Basic realm="x", Digest nonce=abc, stale=true`)
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, c := range parseChallenges(s) {
+			if c["scheme"] == "" {
+				t.Fatalf("parseChallenges(%q): challenge without scheme %v", s, c)
+			}
+		}
+	})
+}
@@ -2,74 +2,530 @@ package restreq
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
-	"strings"
+	"net/url"
+	"time"
 )
 
+// defaultClient is used by requests without external http client.
+var defaultClient = &http.Client{
+	CheckRedirect: checkRedirect,
+}
+
 func (r *Request) do(method string) (*Response, error) {
-	var c httpClient
+	resp, err := r.retry(method)
+	if r.methodFallback != nil {
+		resp, err = r.fallback(method, resp, err)
+	}
+	if err != nil && r.errorFallback != nil {
+		if resp != nil && resp.streamed {
+			resp.Response.Body.Close()
+		}
+		return r.errorFallback(r, err)
+	}
+	return resp, err
+}
 
-	if r.client == nil {
-		c = &http.Client{
-			Timeout: r.timeout,
+// retry sends request until it succeeds or retries are exhausted.
+func (r *Request) retry(method string) (*Response, error) {
+	retries := r.retryCount
+	if r.streamedBody() {
+		retries = 0
+	}
+
+	start := 0
+	if r.retryState != nil {
+		var err error
+		if start, err = r.resumeRetry(); err != nil {
+			return nil, err
 		}
-	} else {
-		c = r.client
 	}
 
-	payload := &bytes.Buffer{}
+	var wait time.Duration
+	for attempt := start; ; attempt++ {
+		req, cancel, err := r.build(method)
+		if err != nil {
+			return nil, err
+		}
 
-	if len(r.jsonPayload) > 0 {
-		payload.Write(r.jsonPayload)
-	} else {
-		if err := json.NewEncoder(payload).Encode(r.json); err != nil {
+		var resp *Response
+		if r.hedgeMax > 1 && !r.streamedBody() && hedgeable(req) {
+			resp, err = r.sendHedged(method, req, cancel)
+		} else {
+			resp, err = r.send(req, cancel)
+		}
+
+		if r.digest != nil && !r.streamedBody() && r.digest.challenged(resp) {
+			if resp.streamed {
+				resp.Response.Body.Close()
+			}
+			if req, cancel, err = r.build(method); err != nil {
+				return nil, err
+			}
+			resp, err = r.send(req, cancel)
+		}
+
+		if r.stats != nil {
+			r.stats.count(endpointKey(method, r.endpoint), err != nil || resp.StatusCode >= 500, attempt > 0)
+		}
+
+		if attempt >= retries || !r.shouldRetry(resp, err) {
+			r.updateRetry(attempt, 0)
+			return resp, err
+		}
+
+		if resp != nil && resp.streamed {
+			resp.Response.Body.Close()
+		}
+
+		wait = r.retryDelay(attempt, wait, resp)
+		if err := r.saveRetry(attempt, wait); err != nil {
+			return resp, err
+		}
+		if err := r.sleep(wait); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// streamedBody reports whether the body can be read only once.
+func (r *Request) streamedBody() bool {
+	return r.jsonStream != nil || r.bodySource != nil || r.hasReaders()
+}
+
+// build creates http.Request to send, with timeout and rate limit
+// applied. The returned cancel func releases its context with cause.
+func (r *Request) build(method string) (*http.Request, func(error), error) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := withCancelCause(ctx)
+	if r.timeout > 0 {
+		var stop context.CancelFunc
+		ctx, stop = withTimeoutCause(ctx, r.timeout, ErrRequestTimeout)
+		cancelCause := cancel
+		cancel = func(cause error) {
+			cancelCause(cause)
+			stop()
+		}
+	}
+	if r.inflight != nil {
+		cancel = r.inflight.add(cancel)
+	}
+
+	start := time.Now()
+	req, err := r.newRequest(r.withTimings(ctx), method, true)
+	if err != nil {
+		cancel(nil)
+		return nil, nil, err
+	}
+
+	t := timingsOf(req)
+	if t != nil {
+		t.Build = time.Since(start) - t.BeforeHooks
+		start = time.Now()
+	}
+
+	if err := r.waitRateLimit(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		cancel(nil)
+		return nil, nil, err
+	}
+
+	if t != nil {
+		t.RateLimit = time.Since(start)
+	}
+
+	return req, cancel, nil
+}
+
+// Build creates http.Request with URL, headers, body, auth and cookies
+// of the request, and runs before hooks, without sending it.
+// It uses context of the request, timeout and rate limit are not applied.
+// No encoding probe is sent, see NegotiateEncoding.
+func (r *Request) Build(method string) (*http.Request, error) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return r.newRequest(ctx, method, false)
+}
+
+// newRequest creates http.Request with context ctx. With probe the
+// encoding can be negotiated with the server.
+func (r *Request) newRequest(ctx context.Context, method string, probe bool) (req *http.Request, err error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	u, err := r.expand(r.url)
+	if err != nil {
+		return nil, err
+	}
+
+	if u, err = r.applyPath(u); err != nil {
+		return nil, err
+	}
+
+	if u, err = r.applyQuery(u); err != nil {
+		return nil, err
+	}
+
+	var quirks *hostQuirks
+	if r.quirks != nil {
+		quirks = r.quirks(u)
+	}
+
+	payload, err := r.body(method)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := r.compression
+	if encoding == "" && r.negotiate && payload != nil && !quirks.disableCompression() {
+		encoding = r.negotiatedEncoding(ctx, u, probe)
+	}
+	if quirks.disableCompression() || payload == nil {
+		encoding = ""
+	}
+
+	length := r.contentLength
+	if f, ok := payload.(fs.File); ok {
+		length = fileSize(f)
+	}
+	source := payload
+	if c, ok := source.(io.Closer); ok {
+		defer func() {
+			if err != nil {
+				c.Close()
+			}
+		}()
+	}
+
+	var prog *progress
+	if r.onProgress != nil && payload != nil {
+		prog = newProgress(r.onProgress, payload, length)
+		if _, ok := payload.(*bytes.Buffer); !ok {
+			payload = prog.countRaw(payload)
+		}
+	}
+
+	if encoding != "" {
+		if payload, err = compress(payload, encoding); err != nil {
 			return nil, err
 		}
 	}
 
-	r.debug(ReqBody, fmt.Sprintf("Body: %s", strings.TrimRight(payload.String(), "\n")))
+	var contentDigest, reprDigest string
+	if len(r.contentDigest) > 0 || len(r.reprDigest) > 0 {
+		var body []byte
+		if payload != nil {
+			b, ok := payload.(*bytes.Buffer)
+			if !ok {
+				return nil, errors.New("digest of streamed body is not supported")
+			}
+			body = b.Bytes()
+		}
+		if len(r.contentDigest) > 0 {
+			if contentDigest, err = digestHeader(r.contentDigest, body); err != nil {
+				return nil, err
+			}
+		}
+		if len(r.reprDigest) > 0 {
+			if reprDigest, err = digestHeader(r.reprDigest, body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if prog != nil {
+		payload = prog.sent(payload, encoding != "")
+	}
+
+	if r.onRedirect != nil {
+		ctx = context.WithValue(ctx, redirectKey{}, r.onRedirect)
+	}
+
+	ctx = r.annotate(ctx)
+
+	if r.tlsSession != nil {
+		ctx = r.tlsSession.trace(ctx)
+	}
+
+	if c, ok := source.(io.Closer); ok && payload != source {
+		payload = &bodyCloser{Reader: payload, source: c}
+	}
 
-	req, err := http.NewRequest(method, r.url, payload)
+	req, err = http.NewRequestWithContext(ctx, method, u, payload)
 	if err != nil {
 		return nil, err
 	}
 
-	if r.ctx != nil {
-		req = req.WithContext(r.ctx)
+	if (r.bodySource != nil || r.bodyFile != nil) && length > 0 && encoding == "" {
+		req.ContentLength = length
+	}
+
+	if prog != nil {
+		prog.setBody(req)
 	}
 
 	for k, v := range r.headers {
+		if v, err = r.expand(v); err != nil {
+			return nil, err
+		}
 		req.Header.Set(k, v)
-		r.debug(ReqHeaders, fmt.Sprintf("Header: %s: %s", k, v))
+		r.debug(ReqHeaders, fmt.Sprintf("Header: %s: %s", k, r.scrubber.Header(k, v)))
+	}
+
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if contentDigest != "" {
+		req.Header.Set("Content-Digest", contentDigest)
+	}
+	if reprDigest != "" {
+		req.Header.Set("Repr-Digest", reprDigest)
+	}
+
+	r.applyAPIVersion(req)
+
+	if r.retryState != nil && req.Header.Get("Idempotency-Key") == "" {
+		req.Header.Set("Idempotency-Key", r.retryState.IdempotencyKey)
 	}
 
 	if r.username != "" && r.password != "" {
-		r.SetBasicAuth(r.username, r.password)
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	if r.secretAuth != nil {
+		p, err := r.secretAuth.password(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(r.secretAuth.username, p)
+	}
+
+	if r.bearer != nil {
+		t, err := r.bearer.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+t)
+	}
+
+	if r.digest != nil {
+		if err := r.digest.authorize(req); err != nil {
+			return nil, err
+		}
 	}
 
 	for k, v := range r.cookies {
-		r.AddCookie(v)
-		r.debug(ReqCookies, fmt.Sprintf("Cookie: %s: %s", k, v))
+		req.AddCookie(v)
+		r.debug(ReqCookies, fmt.Sprintf("Cookie: %s: %s", k, r.scrubber.Cookie(v)))
+	}
+
+	if r.router != nil {
+		if req, err = r.route(req); err != nil {
+			return nil, err
+		}
 	}
 
+	start := time.Now()
+	if err := r.runBeforeHooks(req); err != nil {
+		return nil, err
+	}
+	if t := timingsOf(req); t != nil {
+		t.BeforeHooks = time.Since(start)
+	}
+
+	if quirks != nil {
+		req = quirks.apply(req)
+	}
+
+	if r.signer != nil {
+		if err := r.signer.Sign(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// send executes req and processes the response.
+func (r *Request) send(req *http.Request, cancel func(error)) (*Response, error) {
+	resp, elapsed, err := r.roundTrip(req)
+	return r.process(req, resp, elapsed, err, cancel)
+}
+
+// roundTrip executes req with http client of the request.
+func (r *Request) roundTrip(req *http.Request) (*http.Response, time.Duration, error) {
+	var c httpClient = defaultClient
+	if r.client != nil {
+		c = r.client
+	}
+	if q := quirksOf(req); q != nil && q.client != nil && r.client == q.base {
+		c = q.client
+	}
+
+	start := time.Now()
 	resp, err := c.Do(req)
+	return resp, time.Since(start), err
+}
+
+// process reads and checks response of req, returned by roundTrip.
+func (r *Request) process(req *http.Request, resp *http.Response, elapsed time.Duration, err error, cancel func(error)) (*Response, error) {
+	var auditErr error
+	if r.auditSink != nil {
+		auditErr = r.audit(req, resp, err, elapsed)
+	}
+
 	if err != nil {
+		err = r.withCause(req.Context(), err)
+		cancel(nil)
 		return nil, err
 	}
 
+	if r.stats != nil {
+		r.stats.observe(req.URL.Host, endpointKey(req.Method, r.endpoint), elapsed)
+	}
+
+	t := timingsOf(req)
+	if t != nil {
+		t.Network = elapsed
+	}
+
 	body := &bytes.Buffer{}
-	if !r.bodyReader {
-		if _, err = io.Copy(body, resp.Body); err != nil {
+	if r.bodyReader {
+		resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	} else {
+		start := time.Now()
+		_, err = io.Copy(body, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			err = r.withCause(req.Context(), err)
+		}
+		cancel(nil)
+		if err != nil {
 			return nil, err
 		}
-		resp.Body.Close()
+		if t != nil {
+			t.Body = time.Since(start)
+		}
 	}
 
-	return &Response{
+	response := &Response{
 		Response: resp,
 		Body:     body.Bytes(),
-	}, nil
+		Route:    routeName(req),
+		streamed: r.bodyReader,
+		envelope: r.envelope,
+		sniff:    r.sniff,
+		timings:  t,
+		decode:   r.decode,
+		codec:    r.codec,
+
+		annotations: r.annotations,
+	}
+
+	r.debugResponse(response)
+
+	if r.fixtureDir != "" && !r.bodyReader {
+		if err := r.recordFixture(req, response); err != nil {
+			return response, err
+		}
+	}
+
+	if r.onDeprecation != nil {
+		r.checkDeprecation(req, response)
+	}
+
+	if len(r.invariants) > 0 {
+		r.checkInvariants(req, response)
+	}
+
+	if auditErr != nil {
+		return response, auditErr
+	}
+
+	if r.verifyDigest {
+		if err := response.VerifyDigest(); err != nil {
+			return response, err
+		}
+	}
+
+	start := time.Now()
+	err = r.runAfterHooks(response)
+	if t != nil {
+		t.AfterHooks = time.Since(start)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	if err := r.detectError(response); err != nil {
+		return response, err
+	}
+
+	if len(r.extract) > 0 {
+		if err := r.extractVars(response); err != nil {
+			return response, err
+		}
+	}
+
+	return response, nil
+}
+
+func (r *Request) debugResponse(resp *Response) {
+	if r.logger == nil {
+		return
+	}
+
+	for k := range resp.Response.Header {
+		r.debug(RespHeaders, fmt.Sprintf("Header: %s: %s", k, r.scrubber.Header(k, resp.Header(k))))
+	}
+
+	for _, c := range resp.Cookies() {
+		r.debug(RespCookies, fmt.Sprintf("Cookie: %s: %s", c.Name, r.scrubber.Cookie(c)))
+	}
+
+	if !resp.streamed {
+		r.debug(RespBody, fmt.Sprintf("Body: %s", bytes.TrimRight(r.scrubber.Body(resp.Body), "\n")))
+	}
+}
+
+// cancelReadCloser releases request context when body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel func(error)
+}
+
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel(nil)
+	return err
+}
+
+type redirectKey struct{}
+
+// checkRedirect keeps the default limit of 10 redirects of http.Client
+// and passes every hop to the OnRedirect callback of the request.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	f, ok := req.Context().Value(redirectKey{}).(func(*http.Request, *url.URL) error)
+	if !ok {
+		return nil
+	}
+	return f(via[len(via)-1], req.URL)
 }
@@ -37,6 +37,30 @@ and parse result. This should be sufficient in most use cases.
 		AddJSONKeyValue("float", 2.34).
 		Post()
 
+- Retry network errors, 429 and 5xx responses with exponential backoff
+
+	resp, err := restreq.New("http://example.com").
+		SetRetryCount(3).
+		SetRetryWaitTime(200 * time.Millisecond).
+		Get()
+
+# Client
+
+- Client shares base URL, default headers, auth and a connection pool between requests
+
+	c := restreq.NewClient("http://example.com/api").
+		SetDefaultHeader("X-TOKEN", authToken).
+		SetTimeout(10 * time.Second)
+
+	resp, err := c.New("/items").Get()
+
+- Path segments and query parameters are escaped
+
+	resp, err := c.New("/items").
+		SetPath(id, "comments").
+		AddQueryParam("sort", "date desc").
+		Get()
+
 # Parsing response
 
 - In the default behavior, the body of the response is copied to Response.Body, and you don't have to
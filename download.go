@@ -0,0 +1,147 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DownloadParallel downloads response body to file at path.
+// If the server answers HEAD with 200 and Accept-Ranges: bytes, the
+// body is fetched with chunks concurrent Range requests written at
+// their offsets, otherwise with a single request.
+// The file is removed if download fails.
+func (r *Request) DownloadParallel(path string, chunks int) error {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = r.download(ctx, f, chunks); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return f.Close()
+}
+
+func (r *Request) download(ctx context.Context, f *os.File, chunks int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	probe := r.clone()
+	probe.ctx = ctx
+	probe.bodyReader = false
+	resp, err := probe.do(http.MethodHead)
+	if err != nil {
+		return err
+	}
+
+	// Servers failing HEAD are downloaded with a single request,
+	// which reports its own status.
+	size := resp.ContentLength
+	if chunks < 2 || resp.StatusCode != http.StatusOK || size < int64(chunks) ||
+		!strings.Contains(resp.Header("Accept-Ranges"), "bytes") {
+		return r.downloadRange(ctx, f, -1, -1)
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	step := size / int64(chunks)
+	for i := 0; i < chunks; i++ {
+		start := int64(i) * step
+		end := start + step - 1
+		if i == chunks-1 {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.downloadRange(ctx, f, start, end); err != nil {
+				once.Do(func() {
+					first = err
+					cancel()
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+	return first
+}
+
+// downloadRange writes bytes start-end of the body at offset start,
+// or the whole body if start is negative.
+func (r *Request) downloadRange(ctx context.Context, f *os.File, start, end int64) error {
+	req := r.clone()
+	req.ctx = ctx
+	req.bodyReader = true
+
+	if start >= 0 {
+		req.headers["Range"] = fmt.Sprintf("bytes=%d-%d", start, end)
+	}
+
+	resp, err := req.do(http.MethodGet)
+	if err != nil {
+		return err
+	}
+	defer resp.Response.Body.Close()
+
+	if start < 0 {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("download: unexpected status %d", resp.StatusCode)
+		}
+		_, err = io.Copy(f, resp.Response.Body)
+		return err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("download: expected status 206, got %d", resp.StatusCode)
+	}
+
+	c, err := ParseContentRange(resp.Header("Content-Range"))
+	if err != nil {
+		return err
+	}
+	if c.Start != start || c.End != end {
+		return fmt.Errorf("download: requested bytes %d-%d, got %d-%d", start, end, c.Start, c.End)
+	}
+
+	n, err := io.Copy(&offsetWriter{f: f, off: start}, resp.Response.Body)
+	if err != nil {
+		return err
+	}
+	if n != c.Len() {
+		return fmt.Errorf("download: short range %d-%d", start, end)
+	}
+	return nil
+}
+
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
@@ -0,0 +1,56 @@
+package restreq
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadParallel(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	tests := []struct {
+		name   string
+		head   int
+		stream bool
+		ranges int
+	}{
+		{"ranges", http.StatusOK, false, 4},
+		{"streamed request", http.StatusOK, true, 4},
+		{"head not allowed", http.StatusMethodNotAllowed, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead && tt.head != http.StatusOK {
+					w.Header().Set("Accept-Ranges", "bytes")
+					w.WriteHeader(tt.head)
+					return
+				}
+				if r.Header.Get("Range") != "" {
+					ranges++
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			}))
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "out")
+			r := New(srv.URL)
+			r.bodyReader = tt.stream
+			if err := r.DownloadParallel(path, 4); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) || ranges != tt.ranges {
+				t.Errorf("downloaded %d bytes with %d ranges", len(got), ranges)
+			}
+		})
+	}
+}
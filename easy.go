@@ -0,0 +1,65 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultClient is used by GetJSON and PostJSON. It has 30s timeout
+// and FailOnError detector, and can be configured like other clients.
+var DefaultClient = NewClient("").
+	SetTimeout(30 * time.Second).
+	AddErrorDetector(FailOnError)
+
+// StatusError is returned by FailOnError for status 4xx and 5xx.
+type StatusError struct {
+	Response *Response
+}
+
+func (e *StatusError) Error() string {
+	req := e.Response.Response.Request
+	if req == nil {
+		return "unexpected status " + e.Response.Status
+	}
+	return fmt.Sprintf("%s %s: unexpected status %s", req.Method, req.URL.Redacted(), e.Response.Status)
+}
+
+// FailOnError is ErrorDetector which returns *StatusError
+// for responses with status 4xx and 5xx.
+func FailOnError(r *Response) error {
+	if r.StatusCode >= 400 {
+		return &StatusError{Response: r}
+	}
+	return nil
+}
+
+// GetJSON sends GET request to url with DefaultClient and decodes
+// JSON response into out, if out is not nil and body is not empty.
+//
+//	var user User
+//	err := restreq.GetJSON(ctx, "https://api.example.com/user", &user)
+func GetJSON(ctx context.Context, url string, out any) error {
+	resp, err := DefaultClient.New(url).Context(ctx).
+		AddHeader("Accept", "application/json").Get()
+	return decodeEasy(resp, err, out)
+}
+
+// PostJSON sends in as JSON body of POST request to url with
+// DefaultClient and decodes JSON response into out as GetJSON.
+func PostJSON(ctx context.Context, url string, in, out any) error {
+	resp, err := DefaultClient.New(url).Context(ctx).
+		AddHeader("Accept", "application/json").
+		SetContentTypeJSON().SetJSONPayload(in).Post()
+	return decodeEasy(resp, err, out)
+}
+
+func decodeEasy(resp *Response, err error, out any) error {
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return resp.DecodeJSON(out)
+}
@@ -0,0 +1,137 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type envelope struct {
+	data string
+	err  string
+}
+
+// EnvelopeError is returned by decoding, when the error path
+// of response envelope holds an error.
+type EnvelopeError struct {
+	StatusCode int
+	Raw        json.RawMessage
+}
+
+func (e *EnvelopeError) Error() string {
+	var s string
+	if json.Unmarshal(e.Raw, &s) == nil {
+		return "response error: " + s
+	}
+
+	var m struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(e.Raw, &m) == nil && m.Message != "" {
+		return "response error: " + m.Message
+	}
+	return "response error: " + string(e.Raw)
+}
+
+// SetResponseEnvelope makes decoding unwrap value at dataPath, e.g.
+// data for {"success":true,"data":{...},"error":null}. If value at
+// errorPath is non-empty object or string, or true, decoding returns
+// *EnvelopeError, also for status 200. Other values, e.g. null, false,
+// 0 or {}, are no error. Empty path is not used.
+func (r *Request) SetResponseEnvelope(dataPath, errorPath string) requester {
+	r.envelope = &envelope{data: dataPath, err: errorPath}
+	return r
+}
+
+// SetResponseEnvelope sets response envelope of every request.
+func (c *Client) SetResponseEnvelope(dataPath, errorPath string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.envelope = &envelope{data: dataPath, err: errorPath}
+	return c
+}
+
+// unwrap returns data of the envelope.
+func (e *envelope) unwrap(b []byte, status int) ([]byte, error) {
+	if e.err != "" {
+		v, err := rawPath(b, e.err)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && isEnvelopeError(v) {
+			return nil, &EnvelopeError{StatusCode: status, Raw: v}
+		}
+	}
+
+	if e.data == "" {
+		return b, nil
+	}
+
+	v, err := rawPath(b, e.data)
+	if err != nil || v == nil {
+		return []byte("null"), err
+	}
+	return v, nil
+}
+
+// isEnvelopeError reports if v at error path is an error.
+func isEnvelopeError(v json.RawMessage) bool {
+	var x any
+	if json.Unmarshal(v, &x) != nil {
+		return false
+	}
+	switch x := x.(type) {
+	case map[string]any:
+		return len(x) > 0
+	case string:
+		return x != ""
+	case bool:
+		return x
+	}
+	return false
+}
+
+func isNull(b []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(b), []byte("null"))
+}
+
+// rawPath returns JSON value at path, nil if missing.
+func rawPath(b []byte, path string) (json.RawMessage, error) {
+	keys, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := json.RawMessage(b)
+	for _, k := range keys {
+		if isNull(v) {
+			return nil, nil
+		}
+
+		i := k.index
+		if i < 0 {
+			var m map[string]json.RawMessage
+			if err := json.Unmarshal(v, &m); err == nil {
+				var ok bool
+				if v, ok = m[k.key]; !ok {
+					return nil, nil
+				}
+				continue
+			}
+			if i, err = strconv.Atoi(k.key); err != nil {
+				return nil, fmt.Errorf("path %q: not an object", path)
+			}
+		}
+
+		var a []json.RawMessage
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil, fmt.Errorf("path %q: not an array", path)
+		}
+		if i >= len(a) {
+			return nil, nil
+		}
+		v = a[i]
+	}
+	return v, nil
+}
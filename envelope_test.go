@@ -0,0 +1,48 @@
+package restreq
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEnvelopeUnwrap(t *testing.T) {
+	e := &envelope{data: "data", err: "error"}
+	tests := []struct {
+		body string
+		want string
+		err  bool
+	}{
+		{`{"data":{"a":1},"error":null}`, `{"a":1}`, false},
+		{`{"data":1}`, `1`, false},
+		{`{"data":1,"error":false}`, `1`, false},
+		{`{"data":1,"error":""}`, `1`, false},
+		{`{"data":1,"error":{}}`, `1`, false},
+		{`{"data":1,"error":[]}`, `1`, false},
+		{`{"data":1,"error":0}`, `1`, false},
+		{`{"error":true}`, ``, true},
+		{`{"error":"denied"}`, ``, true},
+		{`{"error":{"message":"denied"}}`, ``, true},
+		{`{"success":true}`, `null`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			got, err := e.unwrap([]byte(tt.body), 200)
+			var envErr *EnvelopeError
+			if tt.err != errors.As(err, &envErr) || !tt.err && string(got) != tt.want {
+				t.Errorf("unwrap = %s, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestEnvelopeErrorMessage(t *testing.T) {
+	for raw, want := range map[string]string{
+		`"denied"`:             "response error: denied",
+		`{"message":"denied"}`: "response error: denied",
+		`{"code":7}`:           `response error: {"code":7}`,
+	} {
+		if got := (&EnvelopeError{Raw: []byte(raw)}).Error(); got != want {
+			t.Errorf("%s: Error() = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package restreq
+
+import "net/http"
+
+// SetMethodFallback sets methods used when the server responds
+// 405 Method Not Allowed, e.g. PATCH to POST. The fallback request
+// has X-HTTP-Method-Override header with the original method.
+// Requests with streamed body are not sent again.
+func (r *Request) SetMethodFallback(m map[string]string) requester {
+	r.methodFallback = m
+	return r
+}
+
+// SetMethodFallback sets method fallback of every request.
+func (c *Client) SetMethodFallback(m map[string]string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.methodFallback = m
+	return c
+}
+
+// FallbackOnError sets f called with the error when the request fails
+// after retries are exhausted. Its response and error are returned
+// instead, e.g. last known good response from cache or a default.
+func (r *Request) FallbackOnError(f func(req *Request, err error) (*Response, error)) requester {
+	r.errorFallback = f
+	return r
+}
+
+// FallbackOnError sets error fallback of every request.
+func (c *Client) FallbackOnError(f func(req *Request, err error) (*Response, error)) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorFallback = f
+	return c
+}
+
+// fallback sends request with fallback method, if resp
+// is 405 and the method has one.
+func (r *Request) fallback(method string, resp *Response, err error) (*Response, error) {
+	to, ok := r.methodFallback[method]
+	if !ok || resp == nil || resp.StatusCode != http.StatusMethodNotAllowed || r.streamedBody() {
+		return resp, err
+	}
+
+	if resp.streamed {
+		resp.Response.Body.Close()
+	}
+
+	f := r.clone()
+	f.headers["X-HTTP-Method-Override"] = method
+	return f.retry(to)
+}
@@ -0,0 +1,135 @@
+package restreq
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileTokenCache keeps every token in a file in a directory, encrypted
+// with AES-GCM. Refresh is locked with file lock, so it can be shared
+// by processes. It uses flock, or LockFileEx on Windows, on other
+// systems the lock works only within the process.
+type FileTokenCache struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// NewFileTokenCache creates cache in dir with AES key of 16, 24
+// or 32 bytes. The directory is created on first Put.
+func NewFileTokenCache(dir string, key []byte) (*FileTokenCache, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileTokenCache{dir: dir, aead: aead}, nil
+}
+
+// path returns file of key, key is hashed.
+func (c *FileTokenCache) path(key, ext string) string {
+	h := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:16])+ext)
+}
+
+// Get returns token of key.
+func (c *FileTokenCache) Get(_ context.Context, key string) (Token, bool, error) {
+	b, err := os.ReadFile(c.path(key, ".token"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Token{}, false, nil
+	}
+	if err != nil {
+		return Token{}, false, err
+	}
+
+	n := c.aead.NonceSize()
+	if len(b) < n {
+		return Token{}, false, errors.New("token cache: invalid file")
+	}
+	b, err = c.aead.Open(nil, b[:n], b[n:], []byte(key))
+	if err != nil {
+		return Token{}, false, errors.New("token cache: can't decrypt token")
+	}
+
+	var t Token
+	if err := json.Unmarshal(b, &t); err != nil {
+		return Token{}, false, err
+	}
+	return t, true, nil
+}
+
+// Put sets token of key, replacing the file atomically.
+func (c *FileTokenCache) Put(_ context.Context, key string, t Token) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	b = c.aead.Seal(nonce, nonce, b, []byte(key))
+
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key, ".token"))
+}
+
+// Lock locks key with lock file, waiting until ctx is done.
+func (c *FileTokenCache) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(c.path(key, ".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package restreq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixture is a recorded response, written by RecordFixtures and
+// served by package restreqtest.
+type Fixture struct {
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// RecordFixtures writes every response to dir as fixture file named
+// by method and path, for development. Sensitive headers are redacted
+// and the body is scrubbed with the scrubber of the client. Later
+// responses to the same method and path replace the fixture.
+// Streamed responses are not recorded.
+func (c *Client) RecordFixtures(dir string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fixtureDir = dir
+	return c
+}
+
+func (r *Request) recordFixture(req *http.Request, resp *Response) error {
+	f := Fixture{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: resp.StatusCode,
+		Header:     make(http.Header, len(resp.Response.Header)),
+		Body:       string(r.scrubber.Body(resp.Body)),
+	}
+	for k, v := range resp.Response.Header {
+		switch k {
+		case "Content-Encoding", "Content-Length":
+			continue
+		}
+		for _, s := range v {
+			f.Header.Add(k, r.scrubber.Header(k, redactHeader(k, s)))
+		}
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(r.fixtureDir, 0o755); err != nil {
+		return fmt.Errorf("fixture: %w", err)
+	}
+	name := filepath.Join(r.fixtureDir, fixtureName(f.Method, f.Path))
+	tmp, err := os.CreateTemp(r.fixtureDir, ".fixture-*")
+	if err != nil {
+		return fmt.Errorf("fixture: %w", err)
+	}
+	_, err = tmp.Write(append(b, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("fixture: %w", err)
+	}
+	return nil
+}
+
+// fixtureName returns file name of fixture, e.g. GET_users_1.json.
+func fixtureName(method, path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		path = "index"
+	}
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c == '/':
+			return '_'
+		case c < 0x80 && (isAlpha(byte(c)) || isDigit(byte(c)) || c == '-' || c == '.' || c == '_'):
+			return c
+		}
+		return '-'
+	}, path)
+	return method + "_" + name + ".json"
+}
@@ -0,0 +1,100 @@
+package restreq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Step is a single request of a Flow.
+type Step struct {
+	Name string
+	// Request sends the request. Use SetVars(v) and Extract to pass
+	// values between steps.
+	Request func(v *Vars) (*Response, error)
+	// Assert checks the response, optional.
+	Assert func(*Response) error
+	// Rollback undoes the step if a later step fails, optional.
+	Rollback func(v *Vars) error
+}
+
+// Flow runs steps in order, sharing one variable store.
+type Flow struct {
+	vars  *Vars
+	steps []Step
+}
+
+// FlowError is returned by Flow.Run when a step fails.
+type FlowError struct {
+	Step     string
+	Err      error
+	Rollback []error
+}
+
+func (e *FlowError) Error() string {
+	s := fmt.Sprintf("step %s: %s", e.Step, e.Err)
+	if len(e.Rollback) > 0 {
+		r := make([]string, len(e.Rollback))
+		for i, err := range e.Rollback {
+			r[i] = err.Error()
+		}
+		s += fmt.Sprintf(" (rollback: %s)", strings.Join(r, "; "))
+	}
+	return s
+}
+
+func (e *FlowError) Unwrap() error {
+	return e.Err
+}
+
+// NewFlow creates flow with empty variable store.
+func NewFlow() *Flow {
+	return &Flow{vars: NewVars()}
+}
+
+// Vars returns variable store of the flow.
+func (f *Flow) Vars() *Vars {
+	return f.vars
+}
+
+// AddStep adds step at the end of the flow.
+func (f *Flow) AddStep(s Step) *Flow {
+	f.steps = append(f.steps, s)
+	return f
+}
+
+// Run executes steps in order. If a step fails, rollbacks of already
+// finished steps are called in reverse order and *FlowError is returned.
+func (f *Flow) Run() error {
+	for i, s := range f.steps {
+		resp, err := s.Request(f.vars)
+		if err == nil && s.Assert != nil {
+			err = s.Assert(resp)
+		}
+		if err == nil {
+			continue
+		}
+
+		fe := &FlowError{Step: s.Name, Err: err}
+		for j := i - 1; j >= 0; j-- {
+			if rb := f.steps[j].Rollback; rb != nil {
+				if err := rb(f.vars); err != nil {
+					fe.Rollback = append(fe.Rollback, fmt.Errorf("%s: %w", f.steps[j].Name, err))
+				}
+			}
+		}
+		return fe
+	}
+	return nil
+}
+
+// ExpectStatus returns assertion accepting only given status codes.
+func ExpectStatus(codes ...int) func(*Response) error {
+	return func(r *Response) error {
+		for _, c := range codes {
+			if r.StatusCode == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected status %d", r.StatusCode)
+	}
+}
@@ -0,0 +1,44 @@
+package restreq
+
+import "net/url"
+
+// SetFormPayload sets form-urlencoded body and Content-Type
+// application/x-www-form-urlencoded. It replaces JSON body.
+func (r *Request) SetFormPayload(v url.Values) requester {
+	r.form = make(url.Values, len(v))
+	for k, values := range v {
+		r.form[k] = append([]string(nil), values...)
+	}
+	r.headers["Content-Type"] = "application/x-www-form-urlencoded"
+	return r
+}
+
+// AddFormField adds field to form-urlencoded body and sets Content-Type
+// application/x-www-form-urlencoded. It replaces JSON body.
+func (r *Request) AddFormField(key, value string) requester {
+	if r.form == nil {
+		r.form = make(url.Values)
+	}
+	r.form.Add(key, value)
+	r.headers["Content-Type"] = "application/x-www-form-urlencoded"
+	return r
+}
+
+// encodeForm encodes form, values can contain templates.
+func (r *Request) encodeForm() (string, error) {
+	if r.vars == nil {
+		return r.form.Encode(), nil
+	}
+
+	form := make(url.Values, len(r.form))
+	for k, values := range r.form {
+		for _, v := range values {
+			x, err := r.expand(v)
+			if err != nil {
+				return "", err
+			}
+			form.Add(k, x)
+		}
+	}
+	return form.Encode(), nil
+}
@@ -0,0 +1,78 @@
+package restreq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SetGraphQLQuery sets JSON body with GraphQL query and variables.
+// Variables are omitted if empty. It replaces JSON body.
+func (r *Request) SetGraphQLQuery(query string, variables map[string]any) requester {
+	r.SetJSONPayload(struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables,omitempty"`
+	}{query, variables})
+	r.headers["Content-Type"] = "application/json"
+	return r
+}
+
+// GraphQLError is error of GraphQL response.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Path       []any             `json:"path,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation is location of error in GraphQL query.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	p := make([]string, len(e.Path))
+	for i, v := range e.Path {
+		p[i] = fmt.Sprint(v)
+	}
+	return strings.Join(p, ".") + ": " + e.Message
+}
+
+// GraphQLErrors is returned by DecodeGraphQL, when errors
+// of GraphQL response are not empty.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	if len(e) == 1 {
+		return "graphql: " + e[0].Error()
+	}
+	return fmt.Sprintf("graphql: %s (and %d more errors)", e[0].Error(), len(e)-1)
+}
+
+// DecodeGraphQL decodes data of GraphQL response to data.
+// If errors are not empty, it returns GraphQLErrors, after
+// decoding partial data if it is not null.
+func (r *Response) DecodeGraphQL(data any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err := json.Unmarshal(r.Body, &resp); err != nil {
+		return err
+	}
+
+	if len(resp.Data) > 0 && !isNull(resp.Data) && data != nil {
+		if err := r.unmarshalJSON(resp.Data, data); err != nil {
+			return err
+		}
+	}
+
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
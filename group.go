@@ -0,0 +1,124 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RequestGroup sends requests concurrently with shared context.
+// By default the first error cancels the other requests, CollectAll
+// makes all requests run to the end.
+type RequestGroup struct {
+	ctx     context.Context
+	cancel  func(error)
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	collect bool
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// Group creates request group bound to ctx.
+//
+//	g := restreq.Group(ctx)
+//	var user User
+//	var orders *restreq.Response
+//	g.Go(c.New("/user"), http.MethodGet, &user)
+//	g.Go(c.New("/orders"), http.MethodGet, &orders)
+//	err := g.Wait()
+func Group(ctx context.Context) *RequestGroup {
+	ctx, cancel := withCancelCause(ctx)
+	return &RequestGroup{ctx: ctx, cancel: cancel}
+}
+
+// CollectAll makes the group run all requests, even if some fail.
+// Wait returns *GroupError with all errors.
+func (g *RequestGroup) CollectAll() *RequestGroup {
+	g.collect = true
+	return g
+}
+
+// SetLimit limits number of requests sent at once.
+// It must be called before Go.
+func (g *RequestGroup) SetLimit(n int) *RequestGroup {
+	if n > 0 {
+		g.sem = make(chan struct{}, n)
+	}
+	return g
+}
+
+// Go sends r with method in a goroutine, with context of the group.
+// The method is passed to Do, as requests have no method until they
+// are sent, so any method can be used, e.g. http.MethodPost or PURGE.
+// out is set to the response if it is **Response, otherwise the
+// response is decoded into out with Decode, if out is not nil.
+func (g *RequestGroup) Go(r requester, method string, out any) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if g.sem != nil {
+			select {
+			case g.sem <- struct{}{}:
+				defer func() { <-g.sem }()
+			case <-g.ctx.Done():
+				g.fail(g.ctx.Err())
+				return
+			}
+		}
+
+		resp, err := r.Context(g.ctx).Do(method)
+		if err == nil {
+			switch o := out.(type) {
+			case nil:
+			case **Response:
+				*o = resp
+			default:
+				err = resp.Decode(out)
+			}
+		}
+		if err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *RequestGroup) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.errs = append(g.errs, err)
+	if !g.collect {
+		g.cancel(fmt.Errorf("restreq: group canceled after error: %w", err))
+	}
+}
+
+// Wait waits for all requests and returns the first error, or
+// *GroupError with all errors if CollectAll is set.
+func (g *RequestGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	if len(g.errs) == 0 {
+		return nil
+	}
+	if g.collect {
+		return &GroupError{Errs: g.errs}
+	}
+	return g.errs[0]
+}
+
+// GroupError is returned by Wait of group with CollectAll.
+type GroupError struct {
+	Errs []error
+}
+
+func (e *GroupError) Error() string {
+	s := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("%d requests failed: %s", len(e.Errs), strings.Join(s, "; "))
+}
@@ -0,0 +1,8 @@
+//go:build go1.20
+
+package restreq
+
+// Unwrap returns all errors, for errors.Is and errors.As.
+func (e *GroupError) Unwrap() []error {
+	return e.Errs
+}
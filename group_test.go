@@ -0,0 +1,107 @@
+package restreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"method":%q,"path":%q}`, r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	var user struct{ Method, Path string }
+	var purge, none *Response
+	g := Group(context.Background())
+	g.Go(New(srv.URL+"/user"), http.MethodGet, &user)
+	g.Go(New(srv.URL+"/cache"), "PURGE", &purge)
+	g.Go(New(srv.URL+"/ping"), http.MethodPost, nil)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if user.Method != "GET" || user.Path != "/user" {
+		t.Errorf("decoded %+v", user)
+	}
+	if purge == nil || !strings.Contains(string(purge.Body), `"method":"PURGE"`) {
+		t.Errorf("response %v", purge)
+	}
+	if none != nil {
+		t.Error("unused response set")
+	}
+}
+
+func TestGroupFirstError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{"))
+			return
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	var out map[string]any
+	g := Group(context.Background())
+	g.Go(New(srv.URL+"/slow"), http.MethodGet, nil)
+	g.Go(New(srv.URL+"/fail"), http.MethodGet, &out)
+	start := time.Now()
+	err := g.Wait()
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("Wait = %v after %v", err, time.Since(start))
+	}
+	var ge *GroupError
+	if errors.As(err, &ge) {
+		t.Errorf("first error mode returned %v", err)
+	}
+}
+
+func TestGroupCollectAll(t *testing.T) {
+	var active, max int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/ok" {
+			w.Write([]byte("{"))
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	var a, b, c map[string]any
+	g := Group(context.Background()).CollectAll().SetLimit(2)
+	g.Go(New(srv.URL+"/bad1"), http.MethodGet, &a)
+	g.Go(New(srv.URL+"/ok"), http.MethodGet, &b)
+	g.Go(New(srv.URL+"/bad2"), http.MethodGet, &c)
+	var ge *GroupError
+	if err := g.Wait(); !errors.As(err, &ge) || len(ge.Errs) != 2 {
+		t.Fatalf("Wait = %v", err)
+	}
+	if b == nil {
+		t.Error("successful request not decoded")
+	}
+	if max > 2 {
+		t.Errorf("%d requests at once, limit 2", max)
+	}
+}
@@ -0,0 +1,168 @@
+package restreq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// WithHedging sends another identical request if there is no response
+// after delay, up to maxAttempts requests in total. The first response
+// is processed, with after hooks, Extract and other checks, and returned.
+// The other requests are cancelled. A request failed without response
+// starts the next one immediately. Only idempotent methods are hedged,
+// other methods if Idempotency-Key or X-Idempotency-Key header is set.
+// Requests with streamed body are not hedged.
+func (r *Request) WithHedging(delay time.Duration, maxAttempts int) requester {
+	r.hedgeDelay = delay
+	r.hedgeMax = maxAttempts
+	return r
+}
+
+// OnHedgeLost sets hook called with every hedged request whose result
+// is not returned, with its error, ErrHedgeLost if it was cancelled
+// after another request won. Lost requests are also passed to the
+// audit sink. The hook can be called after the request returned.
+func (r *Request) OnHedgeLost(f func(*http.Request, error)) requester {
+	r.onHedgeLost = f
+	return r
+}
+
+// hedgeable reports if req can be sent more than once.
+func hedgeable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+type hedgeResult struct {
+	req     *http.Request
+	resp    *http.Response
+	elapsed time.Duration
+	err     error
+	i       int
+}
+
+func (r *Request) sendHedged(method string, req *http.Request, cancel func(error)) (*Response, error) {
+	results := make(chan hedgeResult, r.hedgeMax)
+	cancels := make([]func(error), 0, r.hedgeMax)
+
+	launch := func(req *http.Request, cancel func(error)) {
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, elapsed, err := r.roundTrip(req)
+			results <- hedgeResult{req: req, resp: resp, elapsed: elapsed, err: err, i: i}
+		}()
+	}
+
+	launch(req, cancel)
+
+	t := time.NewTimer(r.hedgeDelay)
+	defer t.Stop()
+
+	var (
+		last    hedgeResult
+		pending = 1
+	)
+
+	next := func() error {
+		req, cancel, err := r.build(method)
+		if err != nil {
+			return err
+		}
+		launch(req, cancel)
+		pending++
+
+		if !t.Stop() {
+			select {
+			case <-t.C:
+			default:
+			}
+		}
+		t.Reset(r.hedgeDelay)
+		return nil
+	}
+
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				r.cancelHedges(cancels, res.i, results, pending)
+				return r.process(res.req, res.resp, res.elapsed, nil, cancels[res.i])
+			}
+			if last.req != nil {
+				r.hedgeLost(last)
+			}
+			last = res
+			if len(cancels) < r.hedgeMax {
+				if err := next(); err != nil {
+					r.cancelHedges(cancels, -1, results, pending)
+					return nil, err
+				}
+			}
+		case <-t.C:
+			if len(cancels) < r.hedgeMax {
+				if err := next(); err != nil {
+					r.cancelHedges(cancels, -1, results, pending)
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for i, c := range cancels {
+		if i != last.i {
+			c(nil)
+		}
+	}
+	return r.process(last.req, last.resp, last.elapsed, last.err, cancels[last.i])
+}
+
+// cancelHedges cancels all requests but the winner, with cause
+// ErrHedgeLost, and closes bodies of responses which arrive later.
+func (r *Request) cancelHedges(cancels []func(error), winner int, results chan hedgeResult, pending int) {
+	for i, c := range cancels {
+		if i == winner {
+			continue
+		}
+		if winner < 0 {
+			c(nil)
+		} else {
+			c(ErrHedgeLost)
+		}
+	}
+
+	go func() {
+		for ; pending > 0; pending-- {
+			res := <-results
+			if res.resp != nil {
+				res.resp.Body.Close()
+			}
+			if winner >= 0 && (res.err == nil || errors.Is(res.err, context.Canceled)) {
+				res.err = ErrHedgeLost
+			}
+			r.hedgeLost(res)
+		}
+	}()
+}
+
+// hedgeLost reports result of a request which is not returned
+// to the audit sink and OnHedgeLost hook.
+func (r *Request) hedgeLost(res hedgeResult) {
+	err := res.err
+	if err != nil {
+		err = r.withCause(res.req.Context(), err)
+	}
+	if r.auditSink != nil {
+		r.audit(res.req, res.resp, err, res.elapsed)
+	}
+	if r.onHedgeLost != nil {
+		r.onHedgeLost(res.req, err)
+	}
+}
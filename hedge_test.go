@@ -0,0 +1,160 @@
+package restreq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowFirst returns server delaying the first request until it is
+// cancelled, counting requests in n.
+func slowFirst(n *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if atomic.AddInt32(n, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestHedgingProcessesWinnerOnce(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte(`{"id":"fast"}`))
+	}))
+	defer srv.Close()
+
+	var hooks int32
+	vars := NewVars()
+	resp, err := New(srv.URL).
+		WithHedging(20*time.Millisecond, 3).
+		SetVars(vars).
+		Extract(map[string]string{"id": "id"}).
+		OnAfterResponse(func(*Response) error { atomic.AddInt32(&hooks, 1); return nil }).
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `{"id":"fast"}` {
+		t.Errorf("body = %s", resp.Body)
+	}
+	if hooks != 1 {
+		t.Errorf("after hooks ran %d times, want 1", hooks)
+	}
+	if v, _ := vars.Get("id"); v != "fast" {
+		t.Errorf("extracted id = %v, want fast", v)
+	}
+}
+
+func TestHedgingAllFail(t *testing.T) {
+	_, err := New("http://127.0.0.1:1/").WithHedging(time.Millisecond, 3).Get()
+	if err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestHedgingIdempotentOnly(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header string
+		want   int32
+	}{
+		{"get", http.MethodGet, "", 2},
+		{"put", http.MethodPut, "", 2},
+		{"post", http.MethodPost, "", 1},
+		{"patch", http.MethodPatch, "", 1},
+		{"post with key", http.MethodPost, "Idempotency-Key", 2},
+		{"patch with key", http.MethodPatch, "X-Idempotency-Key", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int32
+			srv := slowFirst(&n)
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			r := New(srv.URL).WithHedging(20*time.Millisecond, 2).Context(ctx)
+			if tt.header != "" {
+				r.AddHeader(tt.header, "k1")
+			}
+			r.(*Request).do(tt.method)
+			if got := atomic.LoadInt32(&n); got != tt.want {
+				t.Errorf("sent %d requests, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+type syncAudit struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (a *syncAudit) Audit(rec AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = append(a.records, rec)
+	return nil
+}
+
+func TestHedgingReportsLosers(t *testing.T) {
+	var n int32
+	srv := slowFirst(&n)
+	defer srv.Close()
+
+	lost := make(chan error, 1)
+	sink := &syncAudit{}
+	_, err := New(srv.URL).
+		WithHedging(20*time.Millisecond, 2).
+		SetAuditSink(sink).
+		OnHedgeLost(func(_ *http.Request, err error) { lost <- err }).
+		Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-lost:
+		if !errors.Is(err, ErrHedgeLost) {
+			t.Errorf("lost with %v, want ErrHedgeLost", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("loser not reported")
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.records) != 2 {
+		t.Fatalf("%d audit records, want 2", len(sink.records))
+	}
+	var lostRecords int
+	for _, rec := range sink.records {
+		if strings.Contains(rec.Error, "another hedged request won") {
+			lostRecords++
+		}
+	}
+	if lostRecords != 1 {
+		t.Errorf("audit records %+v", sink.records)
+	}
+}
@@ -0,0 +1,96 @@
+package restreq
+
+import (
+	"crypto/sha512"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner(t *testing.T) {
+	fixed := func(time.Time) string { return "1700000000" }
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		opts   HMACOptions
+		header string
+		want   string
+	}{
+		{
+			name:   "default",
+			method: "POST",
+			url:    "https://api.example.com/orders?id=1",
+			body:   `{"a":1}`,
+			header: "X-Signature",
+			want:   "ba79e844c8c19f34abc627d71c42680e600b0c765da9651a6f56dcb81cf3454f",
+		},
+		{
+			name:   "no body",
+			method: "GET",
+			url:    "https://api.example.com",
+			header: "X-Signature",
+			want:   "accfe24dfc1ed17759b8820ec902b0ad2111a4de6543faf43a882b08e925cb6c",
+		},
+		{
+			name:   "base64 with prefix",
+			method: "POST",
+			url:    "https://api.example.com/orders?id=1",
+			body:   `{"a":1}`,
+			opts:   HMACOptions{Base64: true, Prefix: "sha256=", SignatureHeader: "X-Hub-Signature-256"},
+			header: "X-Hub-Signature-256",
+			want:   "sha256=unnoRMjBnzSrxifXHEJoDmALDHZdqWUab1bcuBzzRU8=",
+		},
+		{
+			name:   "custom message",
+			method: "POST",
+			url:    "https://api.example.com/hooks",
+			body:   `{"a":1}`,
+			opts: HMACOptions{
+				Hash: sha512.New,
+				Message: func(method, path string, body []byte, ts string) []byte {
+					return append([]byte(ts+"."), body...)
+				},
+			},
+			header: "X-Signature",
+			want:   "f71e82e1fa1f01407ac290b050e26290f4b62e4365ccce141b0a5c1a7251c4f3f6ce753871f0124e2ce10da3ab0c31594ae53e607f38866b93b651a05eadb4c8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.body == "" {
+				req.Body = http.NoBody
+			}
+			opts := tt.opts
+			opts.Timestamp = fixed
+			s := &HMACSigner{Secret: []byte("secret"), Options: opts}
+			if err := s.Sign(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get(tt.header); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.header, got, tt.want)
+			}
+			if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+				t.Errorf("X-Timestamp = %s", got)
+			}
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != tt.body {
+				t.Errorf("body consumed: %q", body)
+			}
+		})
+	}
+}
+
+func TestHMACSignerStreamedBody(t *testing.T) {
+	req, _ := http.NewRequest("POST", "https://api.example.com", io.NopCloser(strings.NewReader("x")))
+	if err := (&HMACSigner{Secret: []byte("secret")}).Sign(req); err == nil {
+		t.Error("signed streamed body")
+	}
+}
@@ -0,0 +1,82 @@
+package restreq
+
+import "net/http"
+
+// OnBeforeRequest adds hook called with the final http.Request before
+// it is sent. Hooks are called in order, an error stops the request.
+func (r *Request) OnBeforeRequest(f func(*http.Request) error) requester {
+	r.beforeHooks = append(r.beforeHooks, f)
+	return r
+}
+
+// OnAfterResponse adds hook called with the response. Hooks are called
+// in order, an error stops the chain and is returned with the response.
+func (r *Request) OnAfterResponse(f func(*Response) error) requester {
+	r.afterHooks = append(r.afterHooks, f)
+	return r
+}
+
+// OnBeforeRequest adds hook called for every request before
+// the hooks of the request.
+func (c *Client) OnBeforeRequest(f func(*http.Request) error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.beforeHooks = append(c.beforeHooks, f)
+	return c
+}
+
+// OnAfterResponse adds hook called for every response before
+// the hooks of the request.
+func (c *Client) OnAfterResponse(f func(*Response) error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.afterHooks = append(c.afterHooks, f)
+	return c
+}
+
+func (r *Request) runBeforeHooks(req *http.Request) error {
+	for _, f := range r.beforeHooks {
+		if err := f(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Request) runAfterHooks(resp *Response) error {
+	for _, f := range r.afterHooks {
+		if err := f(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrorDetector can declare a response an error, e.g. status 200 with
+// an error in the body. The error is returned with the response and
+// passed to the retry condition.
+type ErrorDetector func(*Response) error
+
+// AddErrorDetector adds detector called after OnAfterResponse hooks.
+func (r *Request) AddErrorDetector(f ErrorDetector) requester {
+	r.errorDetectors = append(r.errorDetectors, f)
+	return r
+}
+
+// AddErrorDetector adds detector called for every response before
+// the detectors of the request.
+func (c *Client) AddErrorDetector(f ErrorDetector) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errorDetectors = append(c.errorDetectors, f)
+	return c
+}
+
+func (r *Request) detectError(resp *Response) error {
+	for _, f := range r.errorDetectors {
+		if err := f(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
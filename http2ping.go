@@ -0,0 +1,22 @@
+package restreq
+
+import "time"
+
+type http2Ping struct {
+	readIdle time.Duration
+	timeout  time.Duration
+}
+
+// SetHTTP2HealthCheck makes HTTP/2 connections send PING frame when
+// nothing is received for readIdle, and closes them if there is no
+// answer within timeout, 15s if zero. Dead connections, e.g. dropped
+// by NAT, are replaced before a request is sent on them. It must be
+// set before the client sends requests and needs Go 1.24, older
+// versions ignore it.
+func (c *Client) SetHTTP2HealthCheck(readIdle, timeout time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.http2Ping = &http2Ping{readIdle: readIdle, timeout: timeout}
+	c.eachTransport(c.http2Ping.apply)
+	return c
+}
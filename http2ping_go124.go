@@ -0,0 +1,16 @@
+//go:build go1.24
+
+package restreq
+
+import "net/http"
+
+// apply sets ping timeouts of HTTP/2 config of t.
+func (p *http2Ping) apply(t *http.Transport) {
+	cfg := &http.HTTP2Config{}
+	if t.HTTP2 != nil {
+		*cfg = *t.HTTP2
+	}
+	cfg.SendPingTimeout = p.readIdle
+	cfg.PingTimeout = p.timeout
+	t.HTTP2 = cfg
+}
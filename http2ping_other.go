@@ -0,0 +1,8 @@
+//go:build !go1.24
+
+package restreq
+
+import "net/http"
+
+// apply does nothing, HTTP/2 config of transport needs Go 1.24.
+func (p *http2Ping) apply(*http.Transport) {}
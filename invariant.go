@@ -0,0 +1,143 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AddInvariant adds check of the response, e.g. ExpectStatus or
+// RequireFields. Unlike OnAfterResponse, violations don't fail the
+// request, they are passed to OnViolation, logged by Debug logger
+// and counted in Client stats.
+func (r *Request) AddInvariant(f func(*Response) error) requester {
+	r.invariants = append(r.invariants, f)
+	return r
+}
+
+// OnViolation sets callback invoked with method and URL of the request,
+// without query, and the violated invariant.
+func (r *Request) OnViolation(f func(endpoint string, err error)) requester {
+	r.onViolation = f
+	return r
+}
+
+// AddInvariant adds check of every response before the checks
+// of the request.
+func (c *Client) AddInvariant(f func(*Response) error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invariants = append(c.invariants, f)
+	return c
+}
+
+// OnViolation sets callback invoked for invariant violations
+// of every request.
+func (c *Client) OnViolation(f func(endpoint string, err error)) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onViolation = f
+	return c
+}
+
+// RequireFields returns invariant requiring fields of JSON body
+// at paths like "data.items[0].id".
+func RequireFields(paths ...string) func(*Response) error {
+	return func(resp *Response) error {
+		v, err := decodeAny(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range paths {
+			if _, err := lookupPath(v, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// FieldTypes returns invariant requiring JSON types of fields at paths,
+// one of "string", "number", "bool", "object", "array" or "null".
+func FieldTypes(types map[string]string) func(*Response) error {
+	return func(resp *Response) error {
+		v, err := decodeAny(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		for p, want := range types {
+			x, err := lookupPath(v, p)
+			if err != nil {
+				return err
+			}
+			if got := jsonType(x); got != want {
+				return fmt.Errorf("path %q: got %s, want %s", p, got, want)
+			}
+		}
+		return nil
+	}
+}
+
+func decodeAny(b []byte) (any, error) {
+	var v any
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func (r *Request) checkInvariants(req *http.Request, resp *Response) {
+	if resp.streamed {
+		return
+	}
+
+	var errs []error
+	for _, f := range r.invariants {
+		if err := f(resp); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	endpoint := req.Method + " " + u.String()
+
+	if r.stats != nil {
+		r.stats.violation(endpointKey(req.Method, r.endpoint))
+	}
+
+	for _, err := range errs {
+		if r.logger != nil {
+			r.logger.Printf("invariant violated: %s: %v\n", endpoint, err)
+		}
+		if r.onViolation != nil {
+			r.onViolation(endpoint, err)
+		}
+	}
+}
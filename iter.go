@@ -0,0 +1,123 @@
+//go:build go1.23
+
+package restreq
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// seqRequester holds iterator methods of requester.
+type seqRequester interface {
+	Pages() iter.Seq2[*Response, error]
+	EventSeq() iter.Seq2[Event, error]
+}
+
+// errStopIter stops callback API when the loop over iterator breaks.
+var errStopIter = errors.New("iteration stopped")
+
+// Pages returns iterator over pages of GET request, following
+// Link rel="next" header of every response until it is missing.
+// Iteration stops after the first error.
+//
+//	for resp, err := range req.Pages() {
+//		...
+//	}
+func (r *Request) Pages() iter.Seq2[*Response, error] {
+	return func(yield func(*Response, error) bool) {
+		seen := map[string]bool{}
+		for p := r; p != nil; {
+			resp, err := p.do(http.MethodGet)
+			if !yield(resp, err) || err != nil {
+				return
+			}
+
+			next := nextPage(resp)
+			if next == "" || seen[next] {
+				return
+			}
+			seen[next] = true
+
+			p = r.clone()
+			p.url = next
+			p.path = nil
+			p.query = nil
+		}
+	}
+}
+
+// nextPage returns absolute URL of Link rel="next" of the response.
+func nextPage(resp *Response) string {
+	for _, l := range parseLinks(resp.Response.Header.Values("Link")) {
+		if l.rel != "next" {
+			continue
+		}
+		u, err := url.Parse(l.url)
+		if err != nil {
+			return ""
+		}
+		if resp.Response.Request != nil {
+			u = resp.Response.Request.URL.ResolveReference(u)
+		}
+		return u.String()
+	}
+	return ""
+}
+
+// NDJSONSeq returns iterator over records of newline delimited JSON
+// body, see DecodeNDJSON. Breaking the loop closes streamed body.
+func (r *Response) NDJSONSeq() iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		err := r.DecodeNDJSON(func(m json.RawMessage) error {
+			if !yield(m, nil) {
+				return errStopIter
+			}
+			return nil
+		})
+		if r.streamed {
+			r.Response.Body.Close()
+		}
+		if err != nil && err != errStopIter {
+			yield(nil, err)
+		}
+	}
+}
+
+// EventSeq returns iterator over Server-Sent Events, see Events.
+// Breaking the loop closes the connection.
+func (r *Request) EventSeq() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		err := r.Events(func(e Event) error {
+			if !yield(e, nil) {
+				return errStopIter
+			}
+			return nil
+		})
+		if err != nil && err != errStopIter {
+			yield(Event{}, err)
+		}
+	}
+}
+
+// RangeSeq returns iterator over ranges of 206 Partial Content
+// response, see Ranges. Body of a part is valid only until the next
+// iteration. Breaking the loop closes streamed body.
+func (r *Response) RangeSeq() iter.Seq2[*RangePart, error] {
+	return func(yield func(*RangePart, error) bool) {
+		err := r.Ranges(func(p *RangePart) error {
+			if !yield(p, nil) {
+				return errStopIter
+			}
+			return nil
+		})
+		if r.streamed {
+			r.Response.Body.Close()
+		}
+		if err != nil && err != errStopIter {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !go1.23
+
+package restreq
+
+// seqRequester holds iterator methods of requester, which need Go 1.23.
+type seqRequester interface{}
@@ -0,0 +1,49 @@
+package restreq
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+var jsonFuncs = struct {
+	sync.RWMutex
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+	custom    bool
+}{
+	marshal:   json.Marshal,
+	unmarshal: json.Unmarshal,
+}
+
+// SetJSONCodec replaces encoding/json used by SetJSONPayload,
+// AddJSONKeyValue, DecodeJSON and JSON codec of Decode, e.g. with
+// jsoniter or go-json. Nil function keeps encoding/json.
+// UseNumber works only with encoding/json.
+func SetJSONCodec(marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) {
+	jsonFuncs.Lock()
+	defer jsonFuncs.Unlock()
+
+	jsonFuncs.marshal, jsonFuncs.unmarshal = json.Marshal, json.Unmarshal
+	if marshal != nil {
+		jsonFuncs.marshal = marshal
+	}
+	if unmarshal != nil {
+		jsonFuncs.unmarshal = unmarshal
+	}
+	jsonFuncs.custom = unmarshal != nil
+}
+
+func marshalJSON(v any) ([]byte, error) {
+	jsonFuncs.RLock()
+	f := jsonFuncs.marshal
+	jsonFuncs.RUnlock()
+	return f(v)
+}
+
+// jsonUnmarshal returns unmarshal function of the JSON codec,
+// custom is false for encoding/json.
+func jsonUnmarshal() (f func([]byte, any) error, custom bool) {
+	jsonFuncs.RLock()
+	defer jsonFuncs.RUnlock()
+	return jsonFuncs.unmarshal, jsonFuncs.custom
+}
@@ -0,0 +1,53 @@
+package restreq
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SetJSONRPC sets JSON body with JSON-RPC 2.0 call of method.
+// Params are omitted if nil, and nil id makes notification
+// without id. It replaces JSON body.
+func (r *Request) SetJSONRPC(method string, params any, id any) requester {
+	r.SetJSONPayload(struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  any    `json:"params,omitempty"`
+		ID      any    `json:"id,omitempty"`
+	}{"2.0", method, params, id})
+	r.headers["Content-Type"] = "application/json"
+	return r
+}
+
+// JSONRPCError is returned by DecodeJSONRPC, when error
+// of JSON-RPC response is not null.
+type JSONRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("jsonrpc: %s (%d)", e.Message, e.Code)
+}
+
+// DecodeJSONRPC decodes result of JSON-RPC response to result.
+// If error is not null, it returns *JSONRPCError.
+func (r *Response) DecodeJSONRPC(result any) error {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *JSONRPCError   `json:"error"`
+	}
+	if err := json.Unmarshal(r.Body, &resp); err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if len(resp.Result) == 0 || result == nil {
+		return nil
+	}
+	return r.unmarshalJSON(resp.Result, result)
+}
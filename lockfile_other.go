@@ -0,0 +1,21 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package restreq
+
+import (
+	"os"
+	"sync"
+)
+
+// lockedFiles are lock files locked by the process.
+var lockedFiles sync.Map
+
+// tryLockFile locks f within the process, ok is false if it is locked.
+func tryLockFile(f *os.File) (ok bool, err error) {
+	_, locked := lockedFiles.LoadOrStore(f.Name(), true)
+	return !locked, nil
+}
+
+func unlockFile(f *os.File) {
+	lockedFiles.Delete(f.Name())
+}
@@ -0,0 +1,377 @@
+package restreq
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// SetMsgPackPayload encodes map or struct to MessagePack body and sets
+// Content-Type to application/msgpack. Struct fields are named by
+// msgpack tag or json tag. It replaces JSON body.
+func (r *Request) SetMsgPackPayload(p any) requester {
+	return r.SetPayload("application/msgpack", p)
+}
+
+// DecodeMsgPack decodes MessagePack
+func (r *Response) DecodeMsgPack(s any) error {
+	if ok, err := r.empty(s); ok {
+		return err
+	}
+	defer r.observeDecode(time.Now())
+	return msgpackCodec{}.Unmarshal(r.Body, s)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if err := encodeValue(e, reflect.ValueOf(v), "msgpack", 0); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return e.b, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	d := &msgpackDecoder{b: data}
+	tree, err := d.value(0)
+	if err == nil && len(d.b) > 0 {
+		err = fmt.Errorf("%d bytes after value", len(d.b))
+	}
+	if err == nil {
+		err = decodeInto(tree, v, "msgpack")
+	}
+	if err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	return nil
+}
+
+// msgpackTimestamp is extension type of timestamps.
+const msgpackTimestamp = -1
+
+type msgpackEncoder struct {
+	b []byte
+}
+
+func (e *msgpackEncoder) writeNil() {
+	e.b = append(e.b, 0xc0)
+}
+
+func (e *msgpackEncoder) writeBool(v bool) {
+	if v {
+		e.b = append(e.b, 0xc3)
+	} else {
+		e.b = append(e.b, 0xc2)
+	}
+}
+
+func (e *msgpackEncoder) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		e.writeUint(uint64(v))
+	case v >= -32:
+		e.b = append(e.b, byte(v))
+	case v >= math.MinInt8:
+		e.b = append(e.b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xd2), uint32(v))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xd3), uint64(v))
+	}
+}
+
+func (e *msgpackEncoder) writeUint(v uint64) {
+	switch {
+	case v < 0x80:
+		e.b = append(e.b, byte(v))
+	case v <= math.MaxUint8:
+		e.b = append(e.b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xce), uint32(v))
+	default:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xcf), v)
+	}
+}
+
+func (e *msgpackEncoder) writeFloat32(v float32) {
+	e.b = binary.BigEndian.AppendUint32(append(e.b, 0xca), math.Float32bits(v))
+}
+
+func (e *msgpackEncoder) writeFloat64(v float64) {
+	e.b = binary.BigEndian.AppendUint64(append(e.b, 0xcb), math.Float64bits(v))
+}
+
+func (e *msgpackEncoder) writeString(v string) {
+	n := len(v)
+	switch {
+	case n < 32:
+		e.b = append(e.b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.b = append(e.b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xda), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xdb), uint32(n))
+	}
+	e.b = append(e.b, v...)
+}
+
+func (e *msgpackEncoder) writeBytes(v []byte) {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		e.b = append(e.b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xc5), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xc6), uint32(n))
+	}
+	e.b = append(e.b, v...)
+}
+
+func (e *msgpackEncoder) writeArray(n int) {
+	switch {
+	case n < 16:
+		e.b = append(e.b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xdc), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xdd), uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) writeMap(n int) {
+	switch {
+	case n < 16:
+		e.b = append(e.b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.b = binary.BigEndian.AppendUint16(append(e.b, 0xde), uint16(n))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xdf), uint32(n))
+	}
+}
+
+// writeTime writes timestamp extension in the smallest of
+// 32, 64 and 96 bit formats.
+func (e *msgpackEncoder) writeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xd6, 0xff), uint32(sec))
+	case sec >= 0 && sec>>34 == 0:
+		e.b = binary.BigEndian.AppendUint64(append(e.b, 0xd7, 0xff), nsec<<34|uint64(sec))
+	default:
+		e.b = binary.BigEndian.AppendUint32(append(e.b, 0xc7, 12, 0xff), uint32(nsec))
+		e.b = binary.BigEndian.AppendUint64(e.b, uint64(sec))
+	}
+}
+
+type msgpackDecoder struct {
+	b []byte
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.b) {
+		return nil, errTruncated
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errMaxDepth
+	}
+
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapValue(int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.array(int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.bytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		if err != nil || v > math.MaxInt64 {
+			return v, err
+		}
+		return int64(v), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (c - 0xd0)
+		v, err := d.uint(n)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*n
+		return int64(v<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(uint64(1) << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(int(n), depth)
+	}
+	return nil, fmt.Errorf("invalid code 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) bytes(n uint64) (any, error) {
+	if n > uint64(len(d.b)) {
+		return nil, errTruncated
+	}
+	b, err := d.next(int(n))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, b...), nil
+}
+
+func (d *msgpackDecoder) array(n int, depth int) (any, error) {
+	if n < 0 || n > len(d.b) {
+		return nil, errTruncated
+	}
+	a := make([]any, n)
+	for i := range a {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) mapValue(n int, depth int) (any, error) {
+	if n < 0 || n > len(d.b)/2 {
+		return nil, errTruncated
+	}
+	m := make(mapValue, n)
+	for i := range m {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[i] = mapEntry{key: k, value: v}
+	}
+	return m, nil
+}
+
+// ext decodes extension of n bytes. Timestamps are decoded
+// as time.Time, data of other types as bytes.
+func (d *msgpackDecoder) ext(n uint64) (any, error) {
+	t, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.b)) {
+		return nil, errTruncated
+	}
+	b, _ := d.next(int(n))
+
+	if int8(t[0]) != msgpackTimestamp {
+		return append([]byte{}, b...), nil
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b[:4])
+		sec := int64(binary.BigEndian.Uint64(b[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	}
+	return nil, fmt.Errorf("invalid timestamp length %d", n)
+}
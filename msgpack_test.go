@@ -0,0 +1,106 @@
+package restreq
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMsgPackDecode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"00", int64(0)},
+		{"7f", int64(127)},
+		{"ff", int64(-1)},
+		{"cc80", int64(128)},
+		{"cfffffffffffffffff", uint64(math.MaxUint64)},
+		{"d0df", int64(-33)},
+		{"d3ffffffffffffff9c", int64(-100)},
+		{"ca3f800000", 1.0},
+		{"cb3ff199999999999a", 1.1},
+		{"c0", nil},
+		{"c2", false},
+		{"c3", true},
+		{"a3616263", "abc"},
+		{"d90161", "a"},
+		{"c4020102", []byte{1, 2}},
+		{"920102", []any{int64(1), int64(2)}},
+		{"dc0000", []any{}},
+		{"82a16101a16292c0c3", map[string]any{"a": int64(1), "b": []any{nil, true}}},
+		{"810102", map[any]any{int64(1): int64(2)}},
+		{"d6ff514b67b0", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"d7ff000000044b67b0f2", time.Unix(0x4b67b0f2, 1).UTC()},
+		{"c70cff00000001ffffffffffffffff", time.Unix(-1, 1).UTC()},
+		{"d50102ab", []byte{0x02, 0xab}},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.in)
+		var got any
+		if err := (msgpackCodec{}).Unmarshal(b, &got); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decode %s = %#v, %v, want %#v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMsgPackDecodeInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "c1", "cc", "a2", "92", "81", "8101", "c4ff", "d6ff01", "d4ff01", "0000", "dcffff",
+	} {
+		b, _ := hex.DecodeString(s)
+		var v any
+		if err := (msgpackCodec{}).Unmarshal(b, &v); err == nil {
+			t.Errorf("decode %q = %#v, want error", s, v)
+		}
+	}
+}
+
+func TestMsgPackEncode(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{0, "00"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{200, "ccc8"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{float32(1), "ca3f800000"},
+		{"abc", "a3616263"},
+		{[]byte{1}, "c40101"},
+		{[]string{"a"}, "91a161"},
+		{map[string]bool{"b": false, "a": true}, "82a161c3a162c2"},
+		{time.Unix(1363896240, 0), "d6ff514b67b0"},
+		{time.Unix(-1, 1), "c70cff00000001ffffffffffffffff"},
+	}
+	for _, tt := range tests {
+		b, err := msgpackCodec{}.Marshal(tt.in)
+		if err != nil || hex.EncodeToString(b) != tt.want {
+			t.Errorf("encode %#v = %x, %v, want %s", tt.in, b, err, tt.want)
+		}
+	}
+}
+
+func FuzzMsgPackDecode(f *testing.F) {
+	for _, s := range []string{"82a16101a16292c0c3", "d7ff000000044b67b0f2", "dc000101", "cb3ff199999999999a"} {
+		b, _ := hex.DecodeString(s)
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var v any
+		if err := (msgpackCodec{}).Unmarshal(b, &v); err != nil {
+			return
+		}
+		out, err := msgpackCodec{}.Marshal(v)
+		if err != nil {
+			t.Fatalf("encode %#v decoded from %x: %v", v, b, err)
+		}
+		var v2 any
+		if err := (msgpackCodec{}).Unmarshal(out, &v2); err != nil {
+			t.Fatalf("decode %x encoded from %#v: %v", out, v, err)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package restreq
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type multipartPart struct {
+	name     string
+	fileName string
+	value    string
+	reader   io.Reader
+	file     *fsFile
+}
+
+// AddMultipartField adds field to multipart/form-data body
+// and sets Content-Type with the boundary. It replaces JSON body.
+func (r *Request) AddMultipartField(name, value string) requester {
+	r.addPart(multipartPart{name: name, value: value})
+	return r
+}
+
+// AddFile adds file to multipart/form-data body and sets Content-Type
+// with the boundary. It replaces JSON body. Content of the file is
+// streamed from rd while the request is sent, rd is closed if it
+// implements io.Closer. Requests with files are not retried.
+// Content-Type of the part is detected from extension of fileName.
+func (r *Request) AddFile(fieldName, fileName string, rd io.Reader) requester {
+	r.addPart(multipartPart{name: fieldName, fileName: fileName, reader: rd})
+	return r
+}
+
+// AddMultipartFile adds file name of fsys to multipart/form-data body,
+// e.g. from embed.FS, and sets Content-Type with the boundary.
+// It replaces JSON body. The file is opened for every attempt,
+// so the request can be retried. Content-Type of the part is
+// detected from extension of name.
+func (r *Request) AddMultipartFile(fieldName string, fsys fs.FS, name string) requester {
+	r.addPart(multipartPart{name: fieldName, fileName: path.Base(name), file: &fsFile{fsys: fsys, name: name}})
+	return r
+}
+
+func (r *Request) addPart(p multipartPart) {
+	if r.boundary == "" {
+		r.boundary = randomBoundary()
+	}
+	r.parts = append(r.parts, p)
+	r.headers["Content-Type"] = "multipart/form-data; boundary=" + r.boundary
+}
+
+func randomBoundary() string {
+	var b [30]byte
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%x", b[:])
+}
+
+// hasFiles reports whether multipart body is streamed.
+func (r *Request) hasFiles() bool {
+	for _, p := range r.parts {
+		if p.reader != nil || p.file != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReaders reports whether multipart body can be read only once.
+func (r *Request) hasReaders() bool {
+	for _, p := range r.parts {
+		if p.reader != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// multipartBody returns multipart body, buffered if there are no files.
+func (r *Request) multipartBody() (io.Reader, error) {
+	if !r.hasFiles() {
+		b := &bytes.Buffer{}
+		if err := r.writeParts(b); err != nil {
+			return nil, err
+		}
+		r.debug(ReqBody, fmt.Sprintf("Body: %s", bytes.TrimRight(r.scrubber.Body(b.Bytes()), "\r\n")))
+		return b, nil
+	}
+
+	r.debug(ReqBody, "Body: <multipart stream>")
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(r.writeParts(pw))
+	}()
+	return pr, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func (r *Request) writeParts(w io.Writer) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(r.boundary); err != nil {
+		return err
+	}
+
+	for i, p := range r.parts {
+		if p.reader == nil && p.file == nil {
+			v, err := r.expand(p.value)
+			if err != nil {
+				return err
+			}
+			if err := mw.WriteField(p.name, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ct := mime.TypeByExtension(filepath.Ext(p.fileName))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(p.name), quoteEscaper.Replace(p.fileName)))
+		h.Set("Content-Type", ct)
+
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			closeParts(r.parts[i:])
+			return err
+		}
+		if p.file != nil {
+			err = copyFile(pw, p.file)
+		} else {
+			_, err = io.Copy(pw, p.reader)
+		}
+		closeParts(r.parts[i : i+1])
+		if err != nil {
+			closeParts(r.parts[i+1:])
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+func copyFile(w io.Writer, file *fsFile) error {
+	f, err := file.fsys.Open(file.name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func closeParts(parts []multipartPart) {
+	for _, p := range parts {
+		if c, ok := p.reader.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package restreq
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMultipartDebugScrubbed(t *testing.T) {
+	var out bytes.Buffer
+	req := New("http://example.com")
+	req.AddMultipartField("user", "alice").
+		AddMultipartField("password", "hunter2").
+		SetScrubber(NewScrubber().AddPattern(regexp.MustCompile(`hunter2`))).
+		Debug(log.New(&out, "", 0), ReqBody)
+	if _, err := req.Build("POST"); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); strings.Contains(s, "hunter2") || !strings.Contains(s, "alice") {
+		t.Errorf("debug log = %q", s)
+	}
+}
@@ -0,0 +1,36 @@
+package restreq
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeNDJSON calls f for every record of newline delimited JSON
+// (JSON Lines) body. Empty lines are skipped. It works also with
+// WithBodyReader, decoding the body as it arrives.
+// An error returned by f stops decoding and is returned.
+func (r *Response) DecodeNDJSON(f func(json.RawMessage) error) error {
+	br := bufio.NewReader(r.bodyReader())
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if rec := bytes.TrimSpace(line); len(rec) > 0 {
+			if !json.Valid(rec) {
+				return fmt.Errorf("ndjson: invalid record at line %d", n)
+			}
+			if err := f(json.RawMessage(rec)); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package restreq
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeNDJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{"{\"a\":1}\n[2]\n", []string{`{"a":1}`, `[2]`}, ""},
+		{"\r\n  \"s\" \r\n\n3", []string{`"s"`, `3`}, ""},
+		{"", nil, ""},
+		{"1\n{\n2\n", []string{`1`}, "ndjson: invalid record at line 2"},
+		{"1 2\n", nil, "ndjson: invalid record at line 1"},
+	}
+	for _, tt := range tests {
+		r := &Response{Body: []byte(tt.in)}
+		var got []string
+		err := r.DecodeNDJSON(func(m json.RawMessage) error {
+			got = append(got, string(m))
+			return nil
+		})
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || errString(err) != tt.wantErr {
+			t.Errorf("DecodeNDJSON(%q) = %q, %v, want %q, %s", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDecodeNDJSONStop(t *testing.T) {
+	stop := errors.New("stop")
+	n := 0
+	err := (&Response{Body: []byte("1\n2\n3\n")}).DecodeNDJSON(func(json.RawMessage) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("DecodeNDJSON = %v after %d records", err, n)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func FuzzDecodeNDJSON(f *testing.F) {
+	f.Add([]byte("{\"a\":1}\n[2]\n"))
+	f.Add([]byte("1\r\n\n\"x\""))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_ = (&Response{Body: b}).DecodeNDJSON(func(m json.RawMessage) error {
+			if !json.Valid(m) {
+				t.Fatalf("invalid record %q", m)
+			}
+			return nil
+		})
+	})
+}
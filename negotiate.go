@@ -0,0 +1,170 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// preferred order of request encodings with equal weight
+var encodingPreference = []string{"zstd", "br", "gzip", "deflate"}
+
+// negotiated keeps negotiatedEntry for every scheme and host
+var negotiated sync.Map
+
+// probing keeps channels of probes being sent, closed when done,
+// so concurrent requests to a host wait for a single probe.
+var probing = struct {
+	sync.Mutex
+	m map[string]chan struct{}
+}{m: map[string]chan struct{}{}}
+
+// TTLs of probe results. Failed probes are cached for a shorter time.
+const (
+	negotiateTTL       = time.Hour
+	negotiateFailedTTL = time.Minute
+)
+
+type negotiatedEntry struct {
+	encoding string
+	expires  time.Time
+}
+
+// NegotiateEncoding compresses request body with the best encoding
+// supported by the server. Supported encodings are read from
+// Accept-Encoding header of OPTIONS response (RFC 7694). The probe is
+// sent once per host and the result is kept for an hour, a failed probe
+// for a minute. It is a bare request sent with the http client of the
+// request, without hooks, retries and other processing of the request.
+// Concurrent first requests to a host wait for the same probe.
+// Build sends no probe, it uses the result only if already known.
+// It has no effect if SetRequestCompression is used.
+func (r *Request) NegotiateEncoding() requester {
+	r.negotiate = true
+	return r
+}
+
+// negotiatedEncoding returns encoding for request to URL u. Without
+// probe only encoding already known for the host is returned.
+func (r *Request) negotiatedEncoding(ctx context.Context, u string, probe bool) string {
+	pu, err := url.Parse(u)
+	if err != nil || pu.Host == "" {
+		return ""
+	}
+	key := pu.Scheme + "://" + pu.Host
+
+	for {
+		if e, ok := cachedEncoding(key); ok || !probe {
+			return e
+		}
+
+		probing.Lock()
+		if e, ok := cachedEncoding(key); ok {
+			probing.Unlock()
+			return e
+		}
+		if done, ok := probing.m[key]; ok {
+			probing.Unlock()
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				return ""
+			}
+		}
+		done := make(chan struct{})
+		probing.m[key] = done
+		probing.Unlock()
+
+		e, err := r.probeEncoding(ctx, u)
+		ttl := negotiateTTL
+		if err != nil {
+			ttl = negotiateFailedTTL
+		}
+		negotiated.Store(key, negotiatedEntry{encoding: e, expires: time.Now().Add(ttl)})
+
+		probing.Lock()
+		delete(probing.m, key)
+		probing.Unlock()
+		close(done)
+		return e
+	}
+}
+
+// cachedEncoding returns encoding negotiated for key, if not expired.
+func cachedEncoding(key string) (string, bool) {
+	e, ok := negotiated.Load(key)
+	if !ok || !time.Now().Before(e.(negotiatedEntry).expires) {
+		return "", false
+	}
+	return e.(negotiatedEntry).encoding, true
+}
+
+// probeEncoding sends OPTIONS request to u and returns the best
+// encoding from Accept-Encoding of the response.
+func (r *Request) probeEncoding(ctx context.Context, u string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var c httpClient = defaultClient
+	if r.client != nil {
+		c = r.client
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return "", fmt.Errorf("probe failed with status %d", resp.StatusCode)
+	}
+	return bestEncoding(resp.Header.Get("Accept-Encoding")), nil
+}
+
+// bestEncoding returns registered encoding with the highest weight
+// from Accept-Encoding value.
+func bestEncoding(accept string) string {
+	best, bestQ, bestRank := "", 0.0, len(encodingPreference)
+
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if v := strings.TrimSpace(params); strings.HasPrefix(v, "q=") {
+			if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+				q = f
+			}
+		}
+
+		if q <= 0 || name == "" || name == "identity" {
+			continue
+		}
+		if _, err := compressor(name); err != nil {
+			continue
+		}
+
+		rank := len(encodingPreference)
+		for i, e := range encodingPreference {
+			if e == name {
+				rank = i
+			}
+		}
+
+		if q > bestQ || (q == bestQ && rank < bestRank) {
+			best, bestQ, bestRank = name, q, rank
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,160 @@
+package restreq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNegotiateEncodingBareProbe(t *testing.T) {
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			atomic.AddInt32(&probes, 1)
+			w.Header().Set("Accept-Encoding", "gzip")
+			return
+		}
+		w.Header().Set("Content-Encoding-Seen", r.Header.Get("Content-Encoding"))
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+
+	var hooks int32
+	for i := 0; i < 3; i++ {
+		resp, err := New(srv.URL+"/items").
+			NegotiateEncoding().
+			AddJSONKeyValue("a", "b").
+			OnAfterResponse(func(*Response) error { atomic.AddInt32(&hooks, 1); return nil }).
+			SetVars(NewVars()).
+			Extract(map[string]string{"id": "id"}).
+			Post()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Header("Content-Encoding-Seen"); got != "gzip" {
+			t.Errorf("Content-Encoding = %q, want gzip", got)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("sent %d probes, want 1", probes)
+	}
+	if hooks != 3 {
+		t.Errorf("after hooks ran %d times, want 3", hooks)
+	}
+}
+
+func TestNegotiateEncodingCachesFailure(t *testing.T) {
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			atomic.AddInt32(&probes, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Encoding-Seen", r.Header.Get("Content-Encoding"))
+	}))
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := New(srv.URL).NegotiateEncoding().SetBodyString("x").Post()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.Header("Content-Encoding-Seen"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("sent %d probes, want 1", probes)
+	}
+}
+
+func TestNegotiateEncodingConcurrent(t *testing.T) {
+	var probes int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			atomic.AddInt32(&probes, 1)
+			<-release
+			w.Header().Set("Accept-Encoding", "gzip")
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Encoding-Seen", r.Header.Get("Content-Encoding"))
+	}))
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := New(srv.URL).NegotiateEncoding().SetBodyString("x").Post()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := resp.Header("Content-Encoding-Seen"); got != "gzip" {
+				t.Errorf("Content-Encoding = %q, want gzip", got)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if probes != 1 {
+		t.Errorf("sent %d probes, want 1", probes)
+	}
+}
+
+func TestNegotiateEncodingBuild(t *testing.T) {
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			atomic.AddInt32(&probes, 1)
+			w.Header().Set("Accept-Encoding", "gzip")
+		}
+	}))
+	defer srv.Close()
+
+	req, err := New(srv.URL).NegotiateEncoding().SetBodyString("x").Build(http.MethodPost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if probes != 0 || req.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("Build sent %d probes, Content-Encoding %q", probes, req.Header.Get("Content-Encoding"))
+	}
+
+	if _, err := New(srv.URL).NegotiateEncoding().SetBodyString("x").Post(); err != nil {
+		t.Fatal(err)
+	}
+	req, err = New(srv.URL).NegotiateEncoding().SetBodyString("x").Build(http.MethodPost)
+	if err != nil || req.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Build after probe: Content-Encoding %q, %v", req.Header.Get("Content-Encoding"), err)
+	}
+}
+
+func TestBestEncoding(t *testing.T) {
+	tests := []struct {
+		accept, want string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip, deflate", "gzip"},
+		{"deflate;q=0.9, gzip;q=0.5", "deflate"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"unknown, gzip", "gzip"},
+		{"GZIP", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := bestEncoding(tt.accept); got != tt.want {
+			t.Errorf("bestEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package restreq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuth2Error is error response of OAuth2 token endpoint (RFC 6749).
+type OAuth2Error struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+}
+
+func (e *OAuth2Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: token request failed with status %d", e.StatusCode)
+	}
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return "oauth2: " + e.Code + ": " + e.Description
+}
+
+type oauth2Source struct {
+	tokenURL string
+	id       string
+	secret   string
+	scopes   []string
+	client   httpClient
+}
+
+// SetOAuth2ClientCredentials sets token provider of every request,
+// which fetches token from tokenURL with OAuth2 client credentials
+// grant. The token is kept in cache set by SetTokenCache, in memory
+// by default, and fetched again shortly before it expires.
+func (c *Client) SetOAuth2ClientCredentials(tokenURL, id, secret string, scopes ...string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &oauth2Source{
+		tokenURL: tokenURL,
+		id:       id,
+		secret:   secret,
+		scopes:   scopes,
+		client:   c.httpClient,
+	}
+	cache := c.tokenCache
+	if cache == nil {
+		cache = NewMemoryTokenCache()
+	}
+	key := "oauth2 " + tokenURL + " " + id + " " + strings.Join(scopes, " ")
+	c.clearAuth()
+	c.bearer = &cachedToken{key: key, cache: cache, fetch: s.fetch, oauth2: true}
+	return c
+}
+
+// fetch fetches new token from token endpoint.
+func (s *oauth2Source) fetch(ctx context.Context) (Token, error) {
+	r := New(s.tokenURL).
+		SetHTTPClient(s.client).
+		Context(ctx).
+		SetBasicAuth(url.QueryEscape(s.id), url.QueryEscape(s.secret)).
+		AddHeader("Accept", "application/json").
+		AddFormField("grant_type", "client_credentials")
+	if len(s.scopes) > 0 {
+		r.AddFormField("scope", strings.Join(s.scopes, " "))
+	}
+
+	resp, err := r.Post()
+	if err != nil {
+		return Token{}, fmt.Errorf("oauth2: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		e := &OAuth2Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(resp.Body, e)
+		return Token{}, e
+	}
+
+	var t struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(resp.Body, &t); err != nil {
+		return Token{}, fmt.Errorf("oauth2: invalid token response: %w", err)
+	}
+	if t.AccessToken == "" {
+		return Token{}, errors.New("oauth2: token response without access_token")
+	}
+	if t.TokenType != "" && !strings.EqualFold(t.TokenType, "bearer") {
+		return Token{}, fmt.Errorf("oauth2: unsupported token type %q", t.TokenType)
+	}
+
+	tok := Token{AccessToken: t.AccessToken}
+	if t.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
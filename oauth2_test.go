@@ -0,0 +1,79 @@
+package restreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var fetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" {
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+			return
+		}
+		fetches++
+		id, secret, _ := r.BasicAuth()
+		if id != "app" || secret != "s%26x" || r.FormValue("grant_type") != "client_credentials" ||
+			r.FormValue("scope") != "read write" {
+			t.Errorf("token request %s:%s %v", id, secret, r.Form)
+		}
+		fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":3600}`, fetches)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL).SetOAuth2ClientCredentials(srv.URL+"/token", "app", "s&x", "read", "write")
+	for i := 0; i < 2; i++ {
+		resp, err := c.New("/items").Get()
+		if err != nil || string(resp.Body) != "Bearer t1" {
+			t.Fatalf("Authorization = %q, %v", resp.Body, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("token fetched %d times, want 1", fetches)
+	}
+}
+
+func TestOAuth2Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   string
+	}{
+		{"error response", http.StatusBadRequest, `{"error":"invalid_client","error_description":"unknown client"}`, "invalid_client"},
+		{"status only", http.StatusInternalServerError, `oops`, ""},
+		{"no token", http.StatusOK, `{"token_type":"bearer"}`, "-"},
+		{"token type", http.StatusOK, `{"access_token":"t","token_type":"mac"}`, "-"},
+		{"invalid json", http.StatusOK, `{`, "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			src := &oauth2Source{tokenURL: srv.URL, id: "app", secret: "s", client: defaultClient}
+			_, err := src.fetch(context.Background())
+			if err == nil {
+				t.Fatal("fetch succeeded")
+			}
+			var e *OAuth2Error
+			if tt.code == "-" {
+				if errors.As(err, &e) {
+					t.Errorf("err = %v, want no OAuth2Error", err)
+				}
+				return
+			}
+			if !errors.As(err, &e) || e.StatusCode != tt.status || e.Code != tt.code {
+				t.Errorf("err = %#v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package boltstore is an example outbox.Store backed by BoltDB.
+//
+// Entries are stored as JSON in a single bucket keyed by entry ID.
+// Due scans the whole bucket, which is fine for an outbox of pending
+// deliveries, but not for a large archive.
+//
+//	db, err := bolt.Open("outbox.db", 0o600, nil)
+//	store, err := boltstore.New(db)
+//	q := outbox.New(store)
+package boltstore
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/scootpl/restreq/outbox"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("outbox")
+
+// Store keeps entries in a single BoltDB bucket.
+type Store struct {
+	db *bolt.DB
+}
+
+// New creates store using db. The bucket is created if missing.
+func New(db *bolt.DB) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Put adds or replaces entry.
+func (s *Store) Put(_ context.Context, e *outbox.Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(e.ID), b)
+	})
+}
+
+// Due returns up to limit entries with NextAttempt before or equal to now.
+func (s *Store) Due(_ context.Context, now time.Time, limit int) ([]*outbox.Entry, error) {
+	var due []*outbox.Entry
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, v []byte) error {
+			e := &outbox.Entry{}
+			if err := json.Unmarshal(v, e); err != nil {
+				return err
+			}
+			if !e.NextAttempt.After(now) {
+				due = append(due, e)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].CreatedAt.Before(due[j].CreatedAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Delete removes entry.
+func (s *Store) Delete(_ context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,10 @@
+module github.com/scootpl/restreq/outbox/boltstore
+
+go 1.19
+
+require (
+	github.com/scootpl/restreq v0.0.0-20261014133546-6fc808051d6e
+	go.etcd.io/bbolt v1.3.8
+)
+
+require golang.org/x/sys v0.4.0 // indirect
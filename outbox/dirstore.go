@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirStore keeps every entry as a JSON file in a directory.
+// Files are replaced atomically, so entries survive crashes.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates store in dir. The directory is created on first Put.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// Put adds or replaces entry.
+func (d *DirStore) Put(_ context.Context, e *Entry) error {
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), d.path(e.ID))
+}
+
+// Due returns up to limit entries with NextAttempt before or equal to now.
+func (d *DirStore) Due(_ context.Context, now time.Time, limit int) ([]*Entry, error) {
+	files, err := os.ReadDir(d.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(d.dir, f.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		e := &Entry{}
+		if err := json.Unmarshal(b, e); err != nil {
+			return nil, err
+		}
+		if !e.NextAttempt.After(now) {
+			due = append(due, e)
+		}
+	}
+	return oldest(due, limit), nil
+}
+
+// Delete removes entry.
+func (d *DirStore) Delete(_ context.Context, id string) error {
+	err := os.Remove(d.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (d *DirStore) path(id string) string {
+	return filepath.Join(d.dir, id+".json")
+}
@@ -0,0 +1,333 @@
+/*
+Package outbox is a persistent retry queue for fire-and-forget requests.
+
+Requests which fail are kept in a Store and retried with exponential
+backoff, also after the process restarts.
+
+	q := outbox.New(outbox.NewDirStore("/var/lib/app/outbox"))
+
+	err := q.Send(ctx, http.MethodPost, "https://example.com/events", nil, payload)
+
+	go q.Run(ctx, 10*time.Second)
+*/
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scootpl/restreq"
+)
+
+// Entry is a request waiting in the queue. Payload is sent as JSON.
+// IdempotencyKey is sent as Idempotency-Key header with every attempt,
+// so the server can recognize attempts repeated after restart.
+type Entry struct {
+	ID             string          `json:"id"`
+	Method         string          `json:"method"`
+	URL            string          `json:"url"`
+	Header         http.Header     `json:"header,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Attempt        int             `json:"attempt"`
+	NextAttempt    time.Time       `json:"next_attempt"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	LastError      string          `json:"last_error,omitempty"`
+}
+
+// Store persists queued entries.
+type Store interface {
+	// Put adds or replaces entry.
+	Put(ctx context.Context, e *Entry) error
+	// Due returns up to limit entries with NextAttempt before or equal
+	// to now, oldest first. Limit 0 means no limit.
+	Due(ctx context.Context, now time.Time, limit int) ([]*Entry, error)
+	// Delete removes entry.
+	Delete(ctx context.Context, id string) error
+}
+
+// Queue retries entries stored in Store.
+type Queue struct {
+	store       Store
+	base        time.Duration
+	max         time.Duration
+	maxAttempts int
+	batch       int
+	timeout     int
+	onDrop      func(*Entry, error)
+	now         func() time.Time
+}
+
+// New creates queue retrying with backoff from 10s up to 1h,
+// without attempt limit.
+func New(store Store) *Queue {
+	return &Queue{
+		store:   store,
+		base:    10 * time.Second,
+		max:     time.Hour,
+		batch:   100,
+		timeout: 30,
+		now:     time.Now,
+	}
+}
+
+// SetBackoff sets the first wait and the maximum wait between attempts.
+func (q *Queue) SetBackoff(base, max time.Duration) *Queue {
+	q.base = base
+	q.max = max
+	return q
+}
+
+// SetMaxAttempts sets the number of attempts after which the entry is
+// dropped. 0 means retry forever.
+func (q *Queue) SetMaxAttempts(n int) *Queue {
+	q.maxAttempts = n
+	return q
+}
+
+// SetBatchSize sets the number of entries fetched from Store by Flush.
+func (q *Queue) SetBatchSize(n int) *Queue {
+	q.batch = n
+	return q
+}
+
+// SetTimeoutSec sets timeout of a single attempt.
+func (q *Queue) SetTimeoutSec(t int) *Queue {
+	q.timeout = t
+	return q
+}
+
+// OnDrop sets callback invoked when entry is removed without success,
+// because of a permanent error or the attempt limit.
+func (q *Queue) OnDrop(f func(*Entry, error)) *Queue {
+	q.onDrop = f
+	return q
+}
+
+// Enqueue stores request to be sent by the next Flush.
+func (q *Queue) Enqueue(ctx context.Context, method, url string, header http.Header, payload json.RawMessage) (*Entry, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := q.now()
+	e := &Entry{
+		ID:             id,
+		Method:         method,
+		URL:            url,
+		Header:         header,
+		Payload:        payload,
+		NextAttempt:    now,
+		IdempotencyKey: key,
+		CreatedAt:      now,
+	}
+
+	if err := q.store.Put(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Send enqueues request and makes the first attempt immediately.
+// The returned error is only about storing.
+func (q *Queue) Send(ctx context.Context, method, url string, header http.Header, payload json.RawMessage) error {
+	e, err := q.Enqueue(ctx, method, url, header, payload)
+	if err != nil {
+		return err
+	}
+	return q.attempt(ctx, e)
+}
+
+// Flush attempts all entries due when it is called once. Entries
+// rescheduled by Flush wait for the next one, also with zero backoff.
+func (q *Queue) Flush(ctx context.Context) error {
+	now := q.now()
+	seen := make(map[string]bool)
+	for {
+		due, err := q.store.Due(ctx, now, q.batch)
+		if err != nil {
+			return err
+		}
+
+		attempted := 0
+		for _, e := range due {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if seen[e.ID] {
+				continue
+			}
+			seen[e.ID] = true
+			attempted++
+			if err := q.attempt(ctx, e); err != nil {
+				return err
+			}
+		}
+
+		if q.batch == 0 || len(due) < q.batch || attempted == 0 {
+			return nil
+		}
+	}
+}
+
+// Run calls Flush every interval until ctx is done.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if err := q.Flush(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// errPermanent marks errors which are not worth retrying.
+var errPermanent = errors.New("outbox: permanent error")
+
+func (q *Queue) attempt(ctx context.Context, e *Entry) error {
+	err := q.send(ctx, e)
+	if err == nil {
+		return q.store.Delete(ctx, e.ID)
+	}
+
+	e.Attempt++
+	e.LastError = err.Error()
+
+	if errors.Is(err, errPermanent) || (q.maxAttempts > 0 && e.Attempt >= q.maxAttempts) {
+		if q.onDrop != nil {
+			q.onDrop(e, err)
+		}
+		return q.store.Delete(ctx, e.ID)
+	}
+
+	e.NextAttempt = q.now().Add(q.wait(e.Attempt))
+	return q.store.Put(ctx, e)
+}
+
+func (q *Queue) wait(attempt int) time.Duration {
+	d := q.base
+	for i := 1; i < attempt && d < q.max; i++ {
+		d *= 2
+	}
+	if d > q.max {
+		d = q.max
+	}
+	return d
+}
+
+func (q *Queue) send(ctx context.Context, e *Entry) error {
+	r := restreq.New(e.URL).
+		Context(ctx).
+		SetTimeoutSec(q.timeout).
+		SetRetryState(&restreq.RetryState{
+			Attempt:        e.Attempt,
+			IdempotencyKey: e.IdempotencyKey,
+		})
+
+	if len(e.Header) > 0 {
+		r.OnBeforeRequest(func(req *http.Request) error {
+			for k, v := range e.Header {
+				req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+			}
+			return nil
+		})
+	}
+
+	if len(e.Payload) > 0 {
+		r.SetJSONPayload(e.Payload)
+	}
+
+	resp, err := r.Do(e.Method)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("outbox: unexpected status %d", resp.StatusCode)
+	}
+	return fmt.Errorf("%w: unexpected status %d", errPermanent, resp.StatusCode)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryStore keeps entries in memory, they are lost on restart.
+type MemoryStore struct {
+	mu sync.Mutex
+	m  map[string]Entry
+}
+
+// NewMemoryStore creates empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{m: make(map[string]Entry)}
+}
+
+// Put adds or replaces entry.
+func (m *MemoryStore) Put(_ context.Context, e *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[e.ID] = *e
+	return nil
+}
+
+// Due returns up to limit entries with NextAttempt before or equal to now.
+func (m *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []*Entry
+	for _, e := range m.m {
+		if !e.NextAttempt.After(now) {
+			e := e
+			due = append(due, &e)
+		}
+	}
+	return oldest(due, limit), nil
+}
+
+// Delete removes entry.
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, id)
+	return nil
+}
+
+func oldest(e []*Entry, limit int) []*Entry {
+	sort.Slice(e, func(i, j int) bool {
+		return e[i].CreatedAt.Before(e[j].CreatedAt)
+	})
+	if limit > 0 && len(e) > limit {
+		e = e[:limit]
+	}
+	return e
+}
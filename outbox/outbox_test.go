@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSendHeaderValues(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Values("X-Tag")
+	}))
+	defer srv.Close()
+
+	q := New(NewMemoryStore())
+	header := http.Header{"X-Tag": {"a", "b"}}
+	if err := q.Send(context.Background(), http.MethodPost, srv.URL, header, json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("X-Tag = %q, want %q", got, want)
+	}
+}
+
+func TestFlushZeroBackoff(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	q := New(store).SetBackoff(0, 0).SetBatchSize(1)
+	for i := 0; i < 2; i++ {
+		if _, err := q.Enqueue(context.Background(), http.MethodPost, srv.URL, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := q.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || len(store.m) != 2 {
+		t.Errorf("attempts = %d, stored %d", attempts, len(store.m))
+	}
+}
@@ -0,0 +1,170 @@
+package restreq
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathKey is a single segment of a path like auth.tokens[0].value.
+// It is a map key, or a slice index if index is not negative.
+// Wildcards * and [*] are only used by Scrubber.
+type pathKey struct {
+	key   string
+	index int
+	any   bool
+}
+
+func parsePath(path string) ([]pathKey, error) {
+	var keys []pathKey
+
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		var idx []int
+
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, fmt.Errorf("invalid path: %q", path)
+				}
+				n := -2
+				if rest[1:end] != "*" {
+					var err error
+					if n, err = strconv.Atoi(rest[1:end]); err != nil || n < 0 {
+						return nil, fmt.Errorf("invalid path: %q", path)
+					}
+				}
+				idx = append(idx, n)
+				rest = rest[end+1:]
+			}
+		}
+
+		if name == "" && len(idx) == 0 {
+			return nil, fmt.Errorf("invalid path: %q", path)
+		}
+		if name != "" {
+			keys = append(keys, pathKey{key: name, index: -1, any: name == "*"})
+		}
+		for _, n := range idx {
+			keys = append(keys, pathKey{index: n, any: n == -2})
+		}
+	}
+
+	return keys, nil
+}
+
+// setPath sets value at path in m, creating nested
+// maps and slices. Slices are extended with nulls.
+func setPath(m map[string]any, keys []pathKey, value any) error {
+	if keys[0].index >= 0 {
+		return errors.New("path must start with key")
+	}
+	_, err := setPathValue(m, keys, value)
+	return err
+}
+
+func setPathValue(cur any, keys []pathKey, value any) (any, error) {
+	if len(keys) == 0 {
+		return value, nil
+	}
+
+	k := keys[0]
+	if k.any {
+		return nil, errors.New("wildcard not allowed")
+	}
+
+	if k.index < 0 {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			if cur != nil {
+				return nil, fmt.Errorf("key %s of non-object value", k.key)
+			}
+			m = map[string]any{}
+		}
+		v, err := setPathValue(m[k.key], keys[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[k.key] = v
+		return m, nil
+	}
+
+	a, ok := cur.([]any)
+	if !ok && cur != nil {
+		return nil, fmt.Errorf("index %d of non-array value", k.index)
+	}
+	for len(a) <= k.index {
+		a = append(a, nil)
+	}
+	v, err := setPathValue(a[k.index], keys[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	a[k.index] = v
+	return a, nil
+}
+
+// copyPathValue copies maps and slices built by setPath,
+// so clones of request don't share them.
+func copyPathValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[k] = copyPathValue(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(x))
+		for i, e := range x {
+			a[i] = copyPathValue(e)
+		}
+		return a
+	}
+	return v
+}
+
+// lookupPath returns value at path in decoded JSON.
+// Numeric map keys are also used as slice index, so a.0 equals a[0].
+func lookupPath(v any, path string) (any, error) {
+	keys, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		if k.any {
+			return nil, fmt.Errorf("path %q: wildcards are not supported", path)
+		}
+
+		switch t := v.(type) {
+		case map[string]any:
+			if k.index >= 0 {
+				return nil, fmt.Errorf("path %q: not an array", path)
+			}
+			var ok bool
+			if v, ok = t[k.key]; !ok {
+				return nil, fmt.Errorf("path %q: key %q not found", path, k.key)
+			}
+		case []any:
+			i := k.index
+			if i < 0 {
+				if i, err = strconv.Atoi(k.key); err != nil {
+					return nil, fmt.Errorf("path %q: not an object", path)
+				}
+			}
+			if i >= len(t) {
+				return nil, fmt.Errorf("path %q: index %d out of range", path, i)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("path %q: cannot traverse %T", path, v)
+		}
+	}
+
+	return v, nil
+}
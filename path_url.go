@@ -0,0 +1,51 @@
+package restreq
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetPath sets path segments appended to the URL, replacing segments
+// of an earlier call. Every segment is escaped, so it can contain
+// slashes or other reserved characters, e.g. SetPath("items", "a/b")
+// gives /items/a%2Fb. Segments can contain templates, which are
+// expanded before escaping.
+func (r *Request) SetPath(segments ...string) requester {
+	r.path = append([]string(nil), segments...)
+	return r
+}
+
+// applyPath appends escaped path segments to u.
+func (r *Request) applyPath(u string) (string, error) {
+	if len(r.path) == 0 {
+		return u, nil
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	p := strings.TrimRight(parsed.EscapedPath(), "/")
+	for _, s := range r.path {
+		if s, err = r.expand(s); err != nil {
+			return "", err
+		}
+		p += "/" + escapeSegment(s)
+	}
+
+	if parsed.Path, err = url.PathUnescape(p); err != nil {
+		return "", err
+	}
+	parsed.RawPath = p
+	return parsed.String(), nil
+}
+
+// escapeSegment escapes path segment, also dot segments,
+// which would be removed by servers normalizing the path.
+func escapeSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
@@ -0,0 +1,33 @@
+package restreq
+
+import "testing"
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		segments [][]string
+		want     string
+	}{
+		{"none", "http://example.com/items", nil, "/items"},
+		{"escaped", "http://example.com/items/", [][]string{{"a/b", "c d"}}, "/items/a%2Fb/c%20d"},
+		{"dot segments", "http://example.com", [][]string{{"..", "."}}, "/%2E%2E/%2E"},
+		{"replaced", "http://example.com/items", [][]string{{"1", "comments"}, {"2"}}, "/items/2"},
+		{"cleared", "http://example.com/items", [][]string{{"1"}, {}}, "/items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.url)
+			for _, s := range tt.segments {
+				r.SetPath(s...)
+			}
+			req, err := r.Build("GET")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.EscapedPath(); got != tt.want {
+				t.Errorf("path = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
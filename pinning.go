@@ -0,0 +1,111 @@
+package restreq
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPinMismatch is matched by errors.Is on PinMismatchError.
+var ErrPinMismatch = errors.New("restreq: certificate pin mismatch")
+
+// PinMismatchError is returned when certificates of the server
+// match none of the pins of SetCertificatePinning.
+type PinMismatchError struct {
+	// Host is server name sent in handshake, empty for IP addresses.
+	Host string
+	// Pins are SPKI pins of the server chain, in sha256/ form.
+	Pins []string
+}
+
+func (e *PinMismatchError) Error() string {
+	s := "restreq: certificate pin mismatch"
+	if e.Host != "" {
+		s += " for " + e.Host
+	}
+	return s + ", got " + strings.Join(e.Pins, ", ")
+}
+
+// Is reports if target is ErrPinMismatch.
+func (e *PinMismatchError) Is(target error) bool {
+	return target == ErrPinMismatch
+}
+
+// SetCertificatePinning makes TLS handshakes fail with PinMismatchError,
+// unless SHA-256 of the leaf certificate or of SubjectPublicKeyInfo of a
+// certificate in the chain matches one of pins. Pins are base64, with
+// optional sha256/ prefix as in HPKP, or hex. Certificates are still
+// verified. It replaces VerifyConnection of the TLS config, pins are
+// kept by later SetTLSConfig and checked by profiles with TLSConfig.
+// Invalid pin is returned by requests of the client.
+func (c *Client) SetCertificatePinning(sha256Pins ...string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pins := make(map[[sha256.Size]byte]bool, len(sha256Pins))
+	for _, p := range sha256Pins {
+		h, err := parsePin(p)
+		if err != nil {
+			c.setErr(err)
+			return c
+		}
+		pins[h] = true
+	}
+
+	c.pins = pins
+	c.eachTLSConfig(func(cfg *tls.Config) {
+		c.applyPins(cfg, nil)
+	})
+	return c
+}
+
+// applyPins sets VerifyConnection of cfg checking pins of the client
+// after verify, if not nil.
+func (c *Client) applyPins(cfg *tls.Config, verify func(tls.ConnectionState) error) {
+	pins := c.pins
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+		return verifyPins(cs, pins)
+	}
+}
+
+func parsePin(p string) ([sha256.Size]byte, error) {
+	var h [sha256.Size]byte
+	s := strings.TrimPrefix(p, "sha256/")
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != sha256.Size {
+		b, err = hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	}
+	if err != nil || len(b) != sha256.Size {
+		return h, fmt.Errorf("invalid certificate pin %q", p)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func verifyPins(cs tls.ConnectionState, pins map[[sha256.Size]byte]bool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return &PinMismatchError{Host: cs.ServerName}
+	}
+	if pins[sha256.Sum256(cs.PeerCertificates[0].Raw)] {
+		return nil
+	}
+
+	got := make([]string, 0, len(cs.PeerCertificates))
+	for _, cert := range cs.PeerCertificates {
+		h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		if pins[h] {
+			return nil
+		}
+		got = append(got, "sha256/"+base64.StdEncoding.EncodeToString(h[:]))
+	}
+	return &PinMismatchError{Host: cs.ServerName, Pins: got}
+}
@@ -0,0 +1,113 @@
+package restreq
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errVerify = errors.New("verify connection")
+
+func pinOf(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(h[:])
+}
+
+func TestSetTLSConfigKeepsPins(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	other := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name string
+		pin  string
+		cfg  *tls.Config
+		want error
+	}{
+		{"matching pin", pinOf(srv.Certificate()), &tls.Config{RootCAs: roots}, nil},
+		{"other pin", other, &tls.Config{RootCAs: roots}, ErrPinMismatch},
+		{"config verify", pinOf(srv.Certificate()), &tls.Config{RootCAs: roots,
+			VerifyConnection: func(tls.ConnectionState) error { return errVerify }}, errVerify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(srv.URL).SetCertificatePinning(tt.pin).SetTLSConfig(tt.cfg)
+			_, err := c.New("/").Get()
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTLSConfigNil(t *testing.T) {
+	c := NewClient("https://example.com").SetTLSConfig(nil)
+	if c.httpClient.Transport.(*http.Transport).TLSClientConfig == nil {
+		t.Fatal("TLS config not reset")
+	}
+
+	c.SetCertificatePinning(pinOf(&x509.Certificate{})).SetTLSConfig(nil)
+	if c.err != nil {
+		t.Fatal(c.err)
+	}
+	if c.httpClient.Transport.(*http.Transport).TLSClientConfig.VerifyConnection == nil {
+		t.Error("pins dropped by SetTLSConfig(nil)")
+	}
+}
+
+func TestProfileKeepsPins(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	other := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	verified := false
+	tests := []struct {
+		name string
+		pin  string
+		cfg  *tls.Config
+		want error
+	}{
+		{"matching pin", pinOf(srv.Certificate()), &tls.Config{RootCAs: roots}, nil},
+		{"other pin", other, &tls.Config{RootCAs: roots}, ErrPinMismatch},
+		{"profile verify", other, &tls.Config{RootCAs: roots,
+			VerifyConnection: func(tls.ConnectionState) error { verified = true; return nil }}, ErrPinMismatch},
+		{"profile verify fails", pinOf(srv.Certificate()), &tls.Config{RootCAs: roots,
+			VerifyConnection: func(tls.ConnectionState) error { return errVerify }}, errVerify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(srv.URL).SetCertificatePinning(tt.pin)
+			c.AddProfile("p", Profile{TLSConfig: tt.cfg})
+			if err := c.WithProfile("p"); err != nil {
+				t.Fatal(err)
+			}
+			_, err := c.New("/").Get()
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if !verified {
+		t.Error("VerifyConnection of profile not called")
+	}
+
+	c := NewClient(srv.URL)
+	c.AddProfile("p", Profile{TLSConfig: &tls.Config{RootCAs: roots}})
+	c.WithProfile("p")
+	if _, err := c.New("/").Get(); err != nil {
+		t.Fatal(err)
+	}
+	c.SetCertificatePinning(other)
+	if _, err := c.New("/").Get(); !errors.Is(err, ErrPinMismatch) {
+		t.Errorf("pins set after profile use: err = %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package restreq
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// EndpointPolicy overrides client defaults for requests to matching paths.
+// Zero fields keep the defaults, negative RetryCount disables retries.
+type EndpointPolicy struct {
+	Timeout          time.Duration
+	RetryCount       int
+	RetryWaitTime    time.Duration
+	RetryMaxWaitTime time.Duration
+}
+
+type endpointPolicy struct {
+	pattern string
+	EndpointPolicy
+}
+
+// SetEndpointPolicy sets policy for paths passed to Client.New
+// matching pattern in path.Match syntax. Pattern ending with /*
+// matches also all deeper paths, e.g. /reports/* matches
+// /reports/2024/q1. The first matching policy is used.
+func (c *Client) SetEndpointPolicy(pattern string, p EndpointPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.policies {
+		if c.policies[i].pattern == pattern {
+			c.policies[i].EndpointPolicy = p
+			return c
+		}
+	}
+	c.policies = append(c.policies, endpointPolicy{pattern: pattern, EndpointPolicy: p})
+	return c
+}
+
+func (c *Client) policy(p string) (EndpointPolicy, bool) {
+	p, _, _ = strings.Cut(p, "?")
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	for _, e := range c.policies {
+		if matchPath(e.pattern, p) {
+			return e.EndpointPolicy, true
+		}
+	}
+	return EndpointPolicy{}, false
+}
+
+func matchPath(pattern, p string) bool {
+	if ok, _ := path.Match(pattern, p); ok {
+		return true
+	}
+	if prefix := strings.TrimSuffix(pattern, "*"); strings.HasSuffix(prefix, "/") && len(prefix) < len(pattern) {
+		return strings.HasPrefix(p, prefix)
+	}
+	return false
+}
+
+func (p EndpointPolicy) apply(r *Request) {
+	if p.Timeout > 0 {
+		r.timeout = p.Timeout
+	}
+	if p.RetryCount > 0 {
+		r.retryCount = p.RetryCount
+	}
+	if p.RetryCount < 0 {
+		r.retryCount = 0
+	}
+	if p.RetryWaitTime > 0 {
+		r.retryWait = p.RetryWaitTime
+	}
+	if p.RetryMaxWaitTime > 0 {
+		r.retryMaxWait = p.RetryMaxWaitTime
+	}
+}
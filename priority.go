@@ -0,0 +1,21 @@
+package restreq
+
+import "fmt"
+
+// SetRequestPriority sets Priority header (RFC 9218) with urgency
+// from 0 (highest) to 7 (lowest), default is 3, and incremental flag
+// for responses which can be processed in parts. Go transports don't
+// send PRIORITY_UPDATE frames, so only the header is sent.
+func (r *Request) SetRequestPriority(urgency int, incremental bool) requester {
+	if urgency < 0 || urgency > 7 {
+		r.setErr(fmt.Errorf("priority urgency %d out of range 0-7", urgency))
+		return r
+	}
+
+	d := SFDict{{Key: "u", Item: SFItem{Value: urgency}}}
+	if incremental {
+		d = append(d, SFDictMember{Key: "i", Item: SFItem{Value: true}})
+	}
+	r.headers["Priority"], _ = FormatSFDict(d)
+	return r
+}
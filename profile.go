@@ -0,0 +1,202 @@
+package restreq
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Profile bundles settings of an environment, e.g. staging.
+// Set fields override defaults of the client. TokenProvider sends
+// bearer token instead of basic auth of Username and Password.
+type Profile struct {
+	BaseURL       string
+	Headers       map[string]string
+	Username      string
+	Password      string
+	TokenProvider TokenProvider
+	TLSConfig     *tls.Config
+}
+
+type profile struct {
+	Profile
+
+	mu         sync.Mutex
+	httpClient *http.Client
+}
+
+// AddProfile adds named profile, which can be activated
+// with WithProfile. A profile with TLSConfig gets its own
+// connection pool. Set fields of TLSConfig override TLS settings
+// of the client, e.g. root CAs and client certificates of the
+// client are used unless the profile sets its own. Pins of
+// SetCertificatePinning are checked after VerifyConnection of
+// the profile.
+func (c *Client) AddProfile(name string, p Profile) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.profiles == nil {
+		c.profiles = make(map[string]*profile)
+	}
+	if p.TLSConfig != nil {
+		p.TLSConfig = p.TLSConfig.Clone()
+	}
+	if old, ok := c.profiles[name]; ok {
+		old.reset()
+	}
+	c.profiles[name] = &profile{Profile: p}
+	return c
+}
+
+// WithProfile activates profile for requests created after the call.
+// Empty name deactivates the profile.
+func (c *Client) WithProfile(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.profiles[name]; !ok && name != "" {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	c.profile = name
+	return nil
+}
+
+// Profile returns name of the active profile.
+func (c *Client) Profile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.profile
+}
+
+func (p *profile) apply(c *Client, r *Request, path string) {
+	if p.BaseURL != "" {
+		r.url = joinURL(p.BaseURL, path)
+		r.baseURL = p.BaseURL
+	}
+	for k, v := range p.Headers {
+		r.headers[k] = v
+	}
+	if p.Username != "" {
+		r.SetBasicAuth(p.Username, p.Password)
+	}
+	if p.TokenProvider != nil {
+		r.SetTokenProvider(p.TokenProvider)
+	}
+	r.client = p.client(c)
+}
+
+// client returns http client of the profile. With TLSConfig it is
+// built on first use from the client transport, so settings of the
+// client made after AddProfile are used. Transport other than
+// *http.Transport is used as is, without TLSConfig.
+func (p *profile) client(c *Client) *http.Client {
+	if p.TLSConfig == nil {
+		return c.httpClient
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.httpClient != nil {
+		return p.httpClient
+	}
+	hc := *c.httpClient
+	if t, ok := hc.Transport.(*http.Transport); ok {
+		t = t.Clone()
+		base := t.TLSClientConfig
+		t.TLSClientConfig = mergeTLS(base, p.TLSConfig)
+		// Pins wrap VerifyConnection of the client, or of the profile
+		// if it sets its own.
+		if c.pins != nil && (base == nil || p.TLSConfig.VerifyConnection != nil) {
+			c.applyPins(t.TLSClientConfig, p.TLSConfig.VerifyConnection)
+		}
+		if c.tlsSession != nil {
+			c.tlsSession.apply(t)
+		}
+		hc.Transport = t
+	}
+	p.httpClient = &hc
+	return p.httpClient
+}
+
+// mergeTLS returns clone of base with set fields of cfg.
+func mergeTLS(base, cfg *tls.Config) *tls.Config {
+	if base == nil {
+		return cfg.Clone()
+	}
+	m := base.Clone()
+	if cfg.Rand != nil {
+		m.Rand = cfg.Rand
+	}
+	if cfg.Time != nil {
+		m.Time = cfg.Time
+	}
+	if cfg.Certificates != nil {
+		m.Certificates = cfg.Certificates
+	}
+	if cfg.GetClientCertificate != nil {
+		m.GetClientCertificate = cfg.GetClientCertificate
+	}
+	if cfg.VerifyPeerCertificate != nil {
+		m.VerifyPeerCertificate = cfg.VerifyPeerCertificate
+	}
+	if cfg.VerifyConnection != nil {
+		m.VerifyConnection = cfg.VerifyConnection
+	}
+	if cfg.RootCAs != nil {
+		m.RootCAs = cfg.RootCAs
+	}
+	if cfg.NextProtos != nil {
+		m.NextProtos = cfg.NextProtos
+	}
+	if cfg.ServerName != "" {
+		m.ServerName = cfg.ServerName
+	}
+	if cfg.InsecureSkipVerify {
+		m.InsecureSkipVerify = true
+	}
+	if cfg.CipherSuites != nil {
+		m.CipherSuites = cfg.CipherSuites
+	}
+	if cfg.ClientSessionCache != nil {
+		m.ClientSessionCache = cfg.ClientSessionCache
+	}
+	if cfg.MinVersion != 0 {
+		m.MinVersion = cfg.MinVersion
+	}
+	if cfg.MaxVersion != 0 {
+		m.MaxVersion = cfg.MaxVersion
+	}
+	if cfg.CurvePreferences != nil {
+		m.CurvePreferences = cfg.CurvePreferences
+	}
+	if cfg.Renegotiation != 0 {
+		m.Renegotiation = cfg.Renegotiation
+	}
+	if cfg.KeyLogWriter != nil {
+		m.KeyLogWriter = cfg.KeyLogWriter
+	}
+	return m
+}
+
+// reset drops http client of the profile, it is built again
+// with current settings of the client.
+func (p *profile) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.httpClient != nil {
+		p.httpClient.CloseIdleConnections()
+		p.httpClient = nil
+	}
+}
+
+// resetProfiles resets profiles after transport settings of the
+// client are changed.
+func (c *Client) resetProfiles() {
+	for _, p := range c.profiles {
+		p.reset()
+	}
+}
@@ -0,0 +1,97 @@
+package restreq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProfileAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		want    string
+	}{
+		{"client", Profile{}, "Bearer client"},
+		{"basic", Profile{Username: "u", Password: "p"}, basicHeader("u", "p")},
+		{"provider", Profile{TokenProvider: testTokens}, "Bearer provided"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("http://example.com").SetBearerToken("client")
+			c.AddProfile("p", tt.profile)
+			if err := c.WithProfile("p"); err != nil {
+				t.Fatal(err)
+			}
+			if got := buildAuth(t, c.New("/")); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileTransport(t *testing.T) {
+	c := NewClient("http://example.com")
+	c.AddProfile("p", Profile{TLSConfig: &tls.Config{ServerName: "staging"}})
+	if err := c.WithProfile("p"); err != nil {
+		t.Fatal(err)
+	}
+	first := profileClient(c)
+
+	c.SetTransport(&http.Transport{MaxConnsPerHost: 7})
+	hc := profileClient(c)
+	tr, ok := hc.Transport.(*http.Transport)
+	if hc == first || !ok || tr.MaxConnsPerHost != 7 || tr.TLSClientConfig.ServerName != "staging" {
+		t.Fatalf("profile transport not rebuilt: %+v", hc.Transport)
+	}
+	if profileClient(c) != hc {
+		t.Error("profile client not reused")
+	}
+
+	c.SetKeepAlive(0)
+	if profileClient(c) == hc {
+		t.Error("profile client kept after socket option")
+	}
+
+	rt := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	c.SetTransport(rt)
+	if _, ok := profileClient(c).Transport.(roundTripperFunc); !ok {
+		t.Error("custom transport not used by profile")
+	}
+}
+
+func TestProfileTLSMerge(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	c := NewClient(srv.URL)
+	c.AddProfile("p", Profile{TLSConfig: &tls.Config{ServerName: "example.com", MinVersion: tls.VersionTLS12}})
+	if err := c.WithProfile("p"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.New("/").Get(); err == nil {
+		t.Fatal("request without root CA succeeded")
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{srv.Certificate().Raw}}
+	c.SetRootCAs(roots).SetClientCertificates(cert)
+	if _, err := c.New("/").Get(); err != nil {
+		t.Fatalf("root CA of the client not used by profile: %v", err)
+	}
+	cfg := profileClient(c).Transport.(*http.Transport).TLSClientConfig
+	if cfg.ServerName != "example.com" || cfg.MinVersion != tls.VersionTLS12 || len(cfg.Certificates) != 1 {
+		t.Errorf("merged config %+v", cfg)
+	}
+}
+
+func profileClient(c *Client) *http.Client {
+	return c.New("/").client.(*http.Client)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
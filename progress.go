@@ -0,0 +1,108 @@
+package restreq
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// Progress of sending request body. Sent counts bytes of the body as sent,
+// after compression, Raw counts bytes before compression. Totals are -1
+// when unknown, e.g. for streamed body.
+type Progress struct {
+	Sent     int64
+	Total    int64
+	Raw      int64
+	RawTotal int64
+}
+
+// OnUploadProgress sets callback invoked while request body is sent.
+// Buffered body is sent with Content-Length of the compressed body,
+// streamed body is sent chunked. Raw bytes of compressed buffered body
+// are estimated from the ratio of sent bytes.
+func (r *Request) OnUploadProgress(f func(Progress)) requester {
+	r.onProgress = f
+	return r
+}
+
+type progress struct {
+	f       func(Progress)
+	p       Progress
+	raw     int64 // atomic, counted by compressing goroutine
+	counted bool
+	buffer  bool
+	buf     []byte
+}
+
+// newProgress creates progress of body, size is length of streamed
+// body if known.
+func newProgress(f func(Progress), body io.Reader, size int64) *progress {
+	p := &progress{f: f, p: Progress{Total: -1, RawTotal: -1}}
+	if b, ok := body.(*bytes.Buffer); ok {
+		p.p.RawTotal = int64(b.Len())
+	} else if size > 0 {
+		p.p.RawTotal = size
+	}
+	return p
+}
+
+// raw counts bytes of streamed body before compression.
+func (p *progress) countRaw(body io.Reader) io.Reader {
+	p.counted = true
+	return &countReader{r: body, f: func(n int) {
+		atomic.AddInt64(&p.raw, int64(n))
+	}}
+}
+
+// sent counts bytes of body as it is sent.
+func (p *progress) sent(body io.Reader, compressed bool) io.Reader {
+	if b, ok := body.(*bytes.Buffer); ok {
+		p.buffer = true
+		p.buf = b.Bytes()
+		p.p.Total = int64(b.Len())
+	} else if !compressed {
+		p.p.Total = p.p.RawTotal
+	}
+
+	return &countReader{r: body, f: func(n int) {
+		p.p.Sent += int64(n)
+		if p.counted {
+			p.p.Raw = atomic.LoadInt64(&p.raw)
+		} else if p.p.Total > 0 {
+			p.p.Raw = p.p.RawTotal * p.p.Sent / p.p.Total
+		}
+		p.f(p.p)
+	}}
+}
+
+// setBody sets length of buffered body lost by wrapping it,
+// streamed body is left with the length set before.
+func (p *progress) setBody(req *http.Request) {
+	if !p.buffer {
+		return
+	}
+	if p.p.Total == 0 {
+		req.Body = http.NoBody
+		return
+	}
+
+	b := p.buf
+	req.ContentLength = p.p.Total
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+}
+
+type countReader struct {
+	r io.Reader
+	f func(int)
+}
+
+func (c *countReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	if n > 0 {
+		c.f(n)
+	}
+	return n, err
+}
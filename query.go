@@ -0,0 +1,86 @@
+package restreq
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// AddQueryParam adds query parameter, which is URL-encoded
+// and appended to the URL when the request is sent.
+func (r *Request) AddQueryParam(key, value string) requester {
+	if r.query == nil {
+		r.query = make(url.Values)
+	}
+	r.query.Add(key, value)
+	return r
+}
+
+// SetQueryParams sets query parameters, replacing values
+// previously added with the same keys.
+func (r *Request) SetQueryParams(m map[string]string) requester {
+	if r.query == nil {
+		r.query = make(url.Values)
+	}
+	for k, v := range m {
+		r.query.Set(k, v)
+	}
+	return r
+}
+
+// AddQueryParamAny adds query parameter with value formatted
+// by fmt.Sprint. Every element of slice is added as separate
+// value, time.Time is formatted as RFC 3339.
+func (r *Request) AddQueryParamAny(key string, value any) requester {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if _, ok := value.([]byte); !ok {
+			for i := 0; i < v.Len(); i++ {
+				r.AddQueryParam(key, queryValue(v.Index(i).Interface()))
+			}
+			return r
+		}
+	}
+	return r.AddQueryParam(key, queryValue(value))
+}
+
+func queryValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// applyQuery appends query parameters to u. Values can contain templates.
+// Query already in u is kept as is, with its order and encoding.
+func (r *Request) applyQuery(u string) (string, error) {
+	if len(r.query) == 0 {
+		return u, nil
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	q := make(url.Values, len(r.query))
+	for k, values := range r.query {
+		for _, v := range values {
+			if v, err = r.expand(v); err != nil {
+				return "", err
+			}
+			q.Add(k, v)
+		}
+	}
+	if parsed.RawQuery != "" {
+		parsed.RawQuery += "&"
+	}
+	parsed.RawQuery += q.Encode()
+	return parsed.String(), nil
+}
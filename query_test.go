@@ -0,0 +1,26 @@
+package restreq
+
+import "testing"
+
+func TestApplyQuery(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/items", "https://example.com/items?b=2&z=a+b"},
+		{"https://example.com/items?", "https://example.com/items?b=2&z=a+b"},
+		{"https://example.com/items?sig=a%2Fb&x=1&x=0", "https://example.com/items?sig=a%2Fb&x=1&x=0&b=2&z=a+b"},
+		{"https://example.com/items?flag", "https://example.com/items?flag&b=2&z=a+b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, err := New(tt.url).AddQueryParam("z", "a b").AddQueryParam("b", "2").Build("GET")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.String(); got != tt.want {
+				t.Errorf("URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package restreq
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SetQueryStruct adds query parameters from fields of struct v.
+// Parameter name is taken from url or query tag, e.g. `url:"q,omitempty"`,
+// fields without tag use field name, "-" skips the field.
+// Options:
+//   - omitempty skips zero values
+//   - unix formats time.Time as unix seconds, by default RFC 3339
+//     or layout from `layout` tag is used
+//
+// Slices add one value per element, nested structs are encoded
+// as parent[child], embedded structs are flattened.
+// Values other than struct or pointer to struct are ignored.
+func (r *Request) SetQueryStruct(v any) requester {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return r
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return r
+	}
+
+	r.addQueryStruct("", rv)
+	return r
+}
+
+func (r *Request) addQueryStruct(prefix string, rv reflect.Value) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag, ok := f.Tag.Lookup("url")
+		if !ok {
+			tag = f.Tag.Get("query")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+
+		if f.Anonymous && name == "" {
+			if fv = indirect(fv); fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				r.addQueryStruct(prefix, fv)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+
+		if hasOption(opts, "omitempty") && isEmpty(fv) {
+			continue
+		}
+
+		fv = indirect(fv)
+		if !fv.IsValid() {
+			continue
+		}
+
+		switch {
+		case fv.Type() == timeType:
+			r.AddQueryParam(name, formatTime(fv.Interface().(time.Time), opts, f.Tag.Get("layout")))
+		case fv.Kind() == reflect.Struct:
+			r.addQueryStruct(name, fv)
+		case (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8:
+			for j := 0; j < fv.Len(); j++ {
+				if e := indirect(fv.Index(j)); e.IsValid() {
+					r.AddQueryParam(name, queryField(e, opts, f.Tag.Get("layout")))
+				}
+			}
+		default:
+			r.AddQueryParam(name, queryField(fv, opts, f.Tag.Get("layout")))
+		}
+	}
+}
+
+func queryField(v reflect.Value, opts, layout string) string {
+	if v.Type() == timeType {
+		return formatTime(v.Interface().(time.Time), opts, layout)
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return string(v.Bytes())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func formatTime(t time.Time, opts, layout string) string {
+	if hasOption(opts, "unix") {
+		return fmt.Sprint(t.Unix())
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.Format(layout)
+}
+
+// indirect dereferences pointers, nil pointer gives invalid value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).IsZero()
+	}
+	return v.IsZero()
+}
+
+func hasOption(opts, name string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
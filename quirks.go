@@ -0,0 +1,108 @@
+package restreq
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Quirks are workarounds for servers with nonstandard behavior.
+type Quirks struct {
+	// DisableCompression asks for uncompressed response with
+	// Accept-Encoding: identity and disables request compression.
+	DisableCompression bool
+	// IdentityOnRange sends Accept-Encoding: identity with Range
+	// requests, for servers breaking ranges of gzipped content.
+	IdentityOnRange bool
+	// ForceHTTP1 disables HTTP/2, using a separate connection pool.
+	ForceHTTP1 bool
+	// StripExpect removes Expect header.
+	StripExpect bool
+	// AcceptCharset is sent as Accept-Charset header, unless it is set.
+	AcceptCharset string
+}
+
+type hostQuirks struct {
+	pattern string
+	Quirks
+	base   httpClient
+	client *http.Client
+}
+
+// SetHostQuirks sets quirks of hosts matching pattern in path.Match
+// syntax, e.g. "*.legacy.example.com". Host is matched without port.
+// The first matching pattern is used. ForceHTTP1 is not used with
+// an external http client.
+func (c *Client) SetHostQuirks(pattern string, q Quirks) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := &hostQuirks{pattern: pattern, Quirks: q, base: c.httpClient}
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok && q.ForceHTTP1 {
+		t := t.Clone()
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		if t.TLSClientConfig != nil {
+			t.TLSClientConfig.NextProtos = nil
+		}
+		h.client = &http.Client{
+			Transport:     t,
+			CheckRedirect: checkRedirect,
+		}
+	}
+
+	for i, e := range c.quirks {
+		if e.pattern == pattern {
+			c.quirks[i] = h
+			return c
+		}
+	}
+	c.quirks = append(c.quirks, h)
+	return c
+}
+
+func (c *Client) hostQuirks(u string) *hostQuirks {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, q := range c.quirks {
+		if ok, _ := path.Match(q.pattern, host); ok {
+			return q
+		}
+	}
+	return nil
+}
+
+func (q *hostQuirks) disableCompression() bool {
+	return q != nil && q.DisableCompression
+}
+
+type quirksKey struct{}
+
+// apply sets headers of req and returns req with quirks in context.
+func (q *hostQuirks) apply(req *http.Request) *http.Request {
+	if q.DisableCompression || (q.IdentityOnRange && req.Header.Get("Range") != "") {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	if q.StripExpect {
+		req.Header.Del("Expect")
+	}
+	if q.AcceptCharset != "" && req.Header.Get("Accept-Charset") == "" {
+		req.Header.Set("Accept-Charset", q.AcceptCharset)
+	}
+	return req.WithContext(context.WithValue(req.Context(), quirksKey{}, q))
+}
+
+func quirksOf(req *http.Request) *hostQuirks {
+	q, _ := req.Context().Value(quirksKey{}).(*hostQuirks)
+	return q
+}
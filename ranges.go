@@ -0,0 +1,137 @@
+package restreq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentRange is parsed Content-Range header. Size is -1 if unknown.
+type ContentRange struct {
+	Start int64
+	End   int64
+	Size  int64
+}
+
+// Len returns number of bytes in the range.
+func (c ContentRange) Len() int64 {
+	return c.End - c.Start + 1
+}
+
+// ParseContentRange parses Content-Range header in the form
+// "bytes 0-499/1234" or "bytes 0-499/*".
+func ParseContentRange(s string) (ContentRange, error) {
+	c := ContentRange{}
+
+	unit, spec, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok || unit != "bytes" {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	rng, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	start, end, ok := strings.Cut(rng, "-")
+	if !ok {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	var err error
+	if c.Start, err = parseRangeInt(start); err != nil {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+	if c.End, err = parseRangeInt(end); err != nil || c.End < c.Start {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	if size == "*" {
+		c.Size = -1
+	} else if c.Size, err = parseRangeInt(size); err != nil || c.End >= c.Size {
+		return c, fmt.Errorf("invalid Content-Range: %q", s)
+	}
+
+	return c, nil
+}
+
+// parseRangeInt parses non-negative decimal without sign.
+func parseRangeInt(s string) (int64, error) {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return 0, strconv.ErrSyntax
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// RangePart is a single range of 206 Partial Content response.
+// Body is valid only inside of the Ranges callback.
+type RangePart struct {
+	ContentRange
+	ContentType string
+	Body        io.Reader
+}
+
+// Ranges calls f for every range of 206 Partial Content response,
+// both for a single range and multipart/byteranges.
+// It works also with WithBodyReader, reading parts as they arrive.
+func (r *Response) Ranges(f func(*RangePart) error) error {
+	if r.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("expected status 206, got %d", r.StatusCode)
+	}
+
+	body := r.bodyReader()
+
+	mt, params, err := mime.ParseMediaType(r.Header("Content-Type"))
+	if err != nil || mt != "multipart/byteranges" {
+		c, err := ParseContentRange(r.Header("Content-Range"))
+		if err != nil {
+			return err
+		}
+		return f(&RangePart{
+			ContentRange: c,
+			ContentType:  r.Header("Content-Type"),
+			Body:         body,
+		})
+	}
+
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		c, err := ParseContentRange(p.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+
+		err = f(&RangePart{
+			ContentRange: c,
+			ContentType:  p.Header.Get("Content-Type"),
+			Body:         p,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// bodyReader returns the reader of a streamed body or of copied Body.
+func (r *Response) bodyReader() io.Reader {
+	if r.streamed {
+		return r.Response.Body
+	}
+	return bytes.NewReader(r.Body)
+}
@@ -0,0 +1,83 @@
+package restreq
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ContentRange
+	}{
+		{"bytes 0-499/1234", ContentRange{0, 499, 1234}},
+		{" bytes 500-999/* ", ContentRange{500, 999, -1}},
+		{"bytes 7-7/8", ContentRange{7, 7, 8}},
+	}
+	for _, tt := range tests {
+		got, err := ParseContentRange(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseContentRange(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseContentRangeInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "bytes", "items 0-1/2", "bytes 0-1", "bytes 1/2", "bytes 5-4/10",
+		"bytes a-1/2", "bytes 0-b/2", "bytes 0-1/c", "bytes -1-1/2", "bytes +1-2/3",
+		"bytes 0-9/5", "bytes 0-4/-5", "bytes */10",
+	} {
+		if c, err := ParseContentRange(s); err == nil {
+			t.Errorf("ParseContentRange(%q) = %v, want error", s, c)
+		}
+	}
+}
+
+func TestRanges(t *testing.T) {
+	body := "--b\r\nContent-Type: text/plain\r\nContent-Range: bytes 0-2/10\r\n\r\nabc\r\n" +
+		"--b\r\nContent-Range: bytes 8-9/10\r\n\r\nij\r\n--b--\r\n"
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   []string
+	}{
+		{"single", http.Header{"Content-Range": {"bytes 3-5/10"}}, "def", []string{"3-5 def"}},
+		{"multipart", http.Header{"Content-Type": {"multipart/byteranges; boundary=b"}}, body, []string{"0-2 abc", "8-9 ij"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Response{
+				Response: &http.Response{StatusCode: http.StatusPartialContent, Header: tt.header},
+				Body:     []byte(tt.body),
+			}
+			var got []string
+			err := r.Ranges(func(p *RangePart) error {
+				b, err := io.ReadAll(p.Body)
+				got = append(got, fmt.Sprintf("%d-%d %s", p.Start, p.End, b))
+				return err
+			})
+			if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Ranges = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseContentRange(f *testing.F) {
+	f.Add("bytes 0-499/1234")
+	f.Add("bytes 500-999/*")
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := ParseContentRange(s)
+		if err != nil {
+			return
+		}
+		if c.Start < 0 || c.End < c.Start || c.Size != -1 && c.End >= c.Size {
+			t.Fatalf("ParseContentRange(%q) = %+v", s, c)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package restreq
+
+import (
+	"context"
+	"net/http"
+)
+
+// RateLimiter throttles requests. *rate.Limiter of golang.org/x/time/rate
+// implements it.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// SetRateLimiter sets limiter waited on before every attempt.
+func (r *Request) SetRateLimiter(l RateLimiter) requester {
+	r.rateLimiter = func(string) RateLimiter { return l }
+	return r
+}
+
+// SetRateLimiter sets limiter shared by all requests of the client.
+// It is used for hosts without limiter set by SetHostRateLimiter.
+func (c *Client) SetRateLimiter(l RateLimiter) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimiter = l
+	return c
+}
+
+// SetHostRateLimiter sets limiter shared by requests of the client
+// to host, as in URL.Host, e.g. example.com:8080.
+func (c *Client) SetHostRateLimiter(host string, l RateLimiter) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hostLimiters == nil {
+		c.hostLimiters = make(map[string]RateLimiter)
+	}
+	c.hostLimiters[host] = l
+	return c
+}
+
+func (c *Client) limiter(host string) RateLimiter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l, ok := c.hostLimiters[host]; ok {
+		return l
+	}
+	return c.rateLimiter
+}
+
+func (r *Request) waitRateLimit(req *http.Request) error {
+	if r.rateLimiter == nil {
+		return nil
+	}
+	l := r.rateLimiter(req.URL.Host)
+	if l == nil {
+		return nil
+	}
+	return l.Wait(req.Context())
+}
@@ -0,0 +1,522 @@
+package restreq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Binary codecs, MessagePack and CBOR, encode Go values through an emitter
+// and decode data to a tree of values, which is assigned to the destination.
+// Struct fields are named by the codec tag, e.g. `msgpack:"name,omitempty"`,
+// or by the json tag.
+
+type emitter interface {
+	writeNil()
+	writeBool(bool)
+	writeInt(int64)
+	writeUint(uint64)
+	writeFloat32(float32)
+	writeFloat64(float64)
+	writeString(string)
+	writeBytes([]byte)
+	writeArray(n int)
+	writeMap(n int)
+	writeTime(time.Time)
+}
+
+// mapValue is a decoded map. Keys can be of any type.
+type mapValue []mapEntry
+
+type mapEntry struct {
+	key   any
+	value any
+}
+
+// maxDepth limits nesting of decoded values.
+const maxDepth = 1000
+
+var (
+	errMaxDepth  = errors.New("maximum nesting depth exceeded")
+	errTruncated = errors.New("unexpected end of data")
+	numberType   = reflect.TypeOf(json.Number(""))
+)
+
+type codecField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// codecFields returns encoded fields of struct type. Fields of embedded
+// structs without name are promoted.
+func codecFields(t reflect.Type, tag string) []codecField {
+	var fields []codecField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		s, ok := f.Tag.Lookup(tag)
+		if !ok {
+			s = f.Tag.Get("json")
+		}
+		if s == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(s, ",")
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct && f.Type != timeType {
+			for _, e := range codecFields(f.Type, tag) {
+				e.index = append([]int{i}, e.index...)
+				fields = append(fields, e)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, codecField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: hasOption(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+func encodeValue(e emitter, v reflect.Value, tag string, depth int) error {
+	if depth > maxDepth {
+		return errMaxDepth
+	}
+	if !v.IsValid() {
+		e.writeNil()
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		e.writeTime(v.Interface().(time.Time))
+		return nil
+	case numberType:
+		n := v.Interface().(json.Number)
+		if n == "" {
+			n = "0"
+		}
+		if i, err := n.Int64(); err == nil {
+			e.writeInt(i)
+		} else if f, err := n.Float64(); err == nil {
+			e.writeFloat64(f)
+		} else {
+			return fmt.Errorf("invalid number %q", n)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.writeNil()
+			return nil
+		}
+		return encodeValue(e, v.Elem(), tag, depth+1)
+	case reflect.Bool:
+		e.writeBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeUint(v.Uint())
+	case reflect.Float32:
+		e.writeFloat32(float32(v.Float()))
+	case reflect.Float64:
+		e.writeFloat64(v.Float())
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.writeNil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeBytes(v.Bytes())
+			return nil
+		}
+		return encodeArray(e, v, tag, depth)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.writeBytes(b)
+			return nil
+		}
+		return encodeArray(e, v, tag, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			e.writeNil()
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		e.writeMap(len(keys))
+		for _, k := range keys {
+			if err := encodeValue(e, k, tag, depth+1); err != nil {
+				return err
+			}
+			if err := encodeValue(e, v.MapIndex(k), tag, depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		var fields []codecField
+		for _, f := range codecFields(v.Type(), tag) {
+			if !f.omitEmpty || !isEmpty(v.FieldByIndex(f.index)) {
+				fields = append(fields, f)
+			}
+		}
+
+		e.writeMap(len(fields))
+		for _, f := range fields {
+			e.writeString(f.name)
+			if err := encodeValue(e, v.FieldByIndex(f.index), tag, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func encodeArray(e emitter, v reflect.Value, tag string, depth int) error {
+	e.writeArray(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := encodeValue(e, v.Index(i), tag, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeInto assigns decoded tree to v, which must be a non-nil pointer.
+func decodeInto(tree any, v any, tag string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode into non-pointer %T", v)
+	}
+	return assign(rv.Elem(), tree, tag)
+}
+
+func assign(dst reflect.Value, src any, tag string) error {
+	if dst.Kind() == reflect.Pointer {
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src, tag)
+	}
+
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Interface {
+		v := reflect.ValueOf(natural(src))
+		if !v.Type().AssignableTo(dst.Type()) {
+			return mismatch(src, dst)
+		}
+		dst.Set(v)
+		return nil
+	}
+
+	if dst.Type() == timeType {
+		t, err := toTime(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if dst.Type() == numberType {
+		switch n := src.(type) {
+		case int64, uint64:
+			dst.SetString(fmt.Sprint(n))
+		case float64:
+			dst.SetString(strconv.FormatFloat(n, 'g', -1, 64))
+		case string:
+			if _, err := strconv.ParseFloat(n, 64); err != nil {
+				return fmt.Errorf("invalid number %q", n)
+			}
+			dst.SetString(n)
+		default:
+			return mismatch(src, dst)
+		}
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt(src)
+		if !ok || dst.OverflowInt(i) {
+			return mismatch(src, dst)
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := toUint(src)
+		if !ok || dst.OverflowUint(u) {
+			return mismatch(src, dst)
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(src)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		dst.SetFloat(f)
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+		case []byte:
+			dst.SetString(string(s))
+		default:
+			return mismatch(src, dst)
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := toBytes(src)
+			if !ok {
+				return mismatch(src, dst)
+			}
+			dst.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		a, ok := src.([]any)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		s := reflect.MakeSlice(dst.Type(), len(a), len(a))
+		for i, x := range a {
+			if err := assign(s.Index(i), x, tag); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := toBytes(src)
+			if !ok {
+				return mismatch(src, dst)
+			}
+			dst.Set(reflect.Zero(dst.Type()))
+			reflect.Copy(dst, reflect.ValueOf(b))
+			return nil
+		}
+		a, ok := src.([]any)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		dst.Set(reflect.Zero(dst.Type()))
+		for i := 0; i < len(a) && i < dst.Len(); i++ {
+			if err := assign(dst.Index(i), a[i], tag); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := src.(mapValue)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(m)))
+		}
+		for _, e := range m {
+			k := reflect.New(dst.Type().Key()).Elem()
+			if err := assign(k, e.key, tag); err != nil {
+				return err
+			}
+			if k.Kind() == reflect.Interface && !k.IsNil() && !k.Elem().Type().Comparable() {
+				return fmt.Errorf("unhashable map key %s", treeType(e.key))
+			}
+			v := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(v, e.value, tag); err != nil {
+				return err
+			}
+			dst.SetMapIndex(k, v)
+		}
+	case reflect.Struct:
+		m, ok := src.(mapValue)
+		if !ok {
+			return mismatch(src, dst)
+		}
+		fields := codecFields(dst.Type(), tag)
+		for _, e := range m {
+			name, ok := e.key.(string)
+			if !ok {
+				continue
+			}
+			if f, ok := findField(fields, name); ok {
+				if err := assign(dst.FieldByIndex(f.index), e.value, tag); err != nil {
+					return fmt.Errorf("field %s: %w", f.name, err)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", dst.Type())
+	}
+	return nil
+}
+
+// findField finds field by name, preferring exact match
+// over case-insensitive one.
+func findField(fields []codecField, name string) (codecField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return codecField{}, false
+}
+
+func mismatch(src any, dst reflect.Value) error {
+	return fmt.Errorf("cannot decode %s into %s", treeType(src), dst.Type())
+}
+
+func treeType(v any) string {
+	switch v.(type) {
+	case mapValue:
+		return "map"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// natural converts decoded tree to values stored in interface,
+// maps with string keys become map[string]any.
+func natural(v any) any {
+	switch t := v.(type) {
+	case mapValue:
+		strKeys := true
+		for _, e := range t {
+			if _, ok := e.key.(string); !ok {
+				strKeys = false
+				break
+			}
+		}
+		if strKeys {
+			m := make(map[string]any, len(t))
+			for _, e := range t {
+				m[e.key.(string)] = natural(e.value)
+			}
+			return m
+		}
+		m := make(map[any]any, len(t))
+		for _, e := range t {
+			k := natural(e.key)
+			if b, ok := k.([]byte); ok {
+				k = string(b)
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				k = fmt.Sprint(k)
+			}
+			m[k] = natural(e.value)
+		}
+		return m
+	case []any:
+		a := make([]any, len(t))
+		for i, x := range t {
+			a[i] = natural(x)
+		}
+		return a
+	}
+	return v
+}
+
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float64:
+		return int64(n), n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64
+	}
+	return 0, false
+}
+
+func toUint(v any) (uint64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case float64:
+		return uint64(n), n == math.Trunc(n) && n >= 0 && n < math.MaxUint64
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toBytes(v any) ([]byte, bool) {
+	switch b := v.(type) {
+	case []byte:
+		return b, true
+	case string:
+		return []byte(b), true
+	}
+	return nil, false
+}
+
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, t)
+	case float64:
+		sec, frac := math.Modf(t)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+	if sec, ok := toInt(v); ok {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot decode %s into time.Time", treeType(v))
+}
@@ -0,0 +1,167 @@
+package restreq
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecBase struct {
+	ID int64 `json:"id"`
+}
+
+type codecRecord struct {
+	codecBase
+	Name     string            `json:"name" msgpack:"n" cbor:"n"`
+	Skip     string            `json:"-"`
+	Empty    string            `json:"empty,omitempty"`
+	Ptr      *float64          `json:"ptr"`
+	Tags     []string          `json:"tags"`
+	Raw      []byte            `json:"raw"`
+	Fixed    [2]byte           `json:"fixed"`
+	Counts   map[string]uint16 `json:"counts"`
+	At       time.Time         `json:"at"`
+	Number   json.Number       `json:"number"`
+	Any      any               `json:"any"`
+	private  int
+	Children []*codecRecord `json:"children"`
+}
+
+var binaryCodecs = []Codec{msgpackCodec{}, cborCodec{}}
+
+func TestCodecRoundTrip(t *testing.T) {
+	f := 1.5
+	in := codecRecord{
+		codecBase: codecBase{ID: -7},
+		Name:      "a",
+		Skip:      "skipped",
+		Ptr:       &f,
+		Tags:      []string{"x", "y"},
+		Raw:       []byte{0, 1},
+		Fixed:     [2]byte{2, 3},
+		Counts:    map[string]uint16{"a": 1, "b": 65535},
+		At:        time.Date(2024, 5, 1, 10, 0, 0, 5, time.UTC),
+		Number:    "12",
+		Any:       map[string]any{"k": []any{int64(1), "v", nil}},
+		private:   1,
+		Children:  []*codecRecord{{Name: "child"}, nil},
+	}
+	for _, c := range binaryCodecs {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			b, err := c.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out codecRecord
+			if err := c.Unmarshal(b, &out); err != nil {
+				t.Fatal(err)
+			}
+			want := in
+			want.Skip, want.private = "", 0
+			want.Children = []*codecRecord{{Name: "child", Number: "0"}, nil}
+			if !reflect.DeepEqual(out, want) {
+				t.Errorf("round trip = %+v\nwant %+v", out, want)
+			}
+
+			var m map[string]any
+			if err := c.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			for _, k := range []string{"id", "n", "ptr", "tags"} {
+				if _, ok := m[k]; !ok {
+					t.Errorf("field %s missing in %v", k, m)
+				}
+			}
+			for _, k := range []string{"Skip", "empty", "private", "name"} {
+				if _, ok := m[k]; ok {
+					t.Errorf("field %s encoded in %v", k, m)
+				}
+			}
+		})
+	}
+}
+
+func TestCodecDecodeMismatch(t *testing.T) {
+	tests := []struct {
+		in   any
+		dst  any
+		want string
+	}{
+		{"x", new(int), "cannot decode string into int"},
+		{300, new(uint8), "cannot decode int64 into uint8"},
+		{-1, new(uint), "cannot decode int64 into uint"},
+		{1.5, new(int), "cannot decode float64 into int"},
+		{[]int{1}, new(map[string]int), "cannot decode array into map[string]int"},
+		{map[string]int{"a": 1}, new([]int), "cannot decode map into []int"},
+		{map[string]string{"id": "x"}, new(codecBase), "field id: cannot decode string into int64"},
+		{true, new(time.Time), "cannot decode bool into time.Time"},
+		{1, codecBase{}, "decode into non-pointer restreq.codecBase"},
+	}
+	for _, c := range binaryCodecs {
+		for _, tt := range tests {
+			b, err := c.Marshal(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Unmarshal(b, tt.dst); err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("%s: decode %#v into %T = %v, want %s", c.ContentType(), tt.in, tt.dst, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestCodecFields(t *testing.T) {
+	var names []string
+	for _, f := range codecFields(reflect.TypeOf(codecRecord{}), "msgpack") {
+		names = append(names, f.name)
+	}
+	want := "id n empty ptr tags raw fixed counts at number any children"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("codecFields = %s, want %s", got, want)
+	}
+
+	f, ok := findField(codecFields(reflect.TypeOf(codecRecord{}), "json"), "NAME")
+	if !ok || f.name != "name" {
+		t.Errorf("findField(NAME) = %v, %v", f, ok)
+	}
+}
+
+func TestCodecMaxDepth(t *testing.T) {
+	var v any = 1
+	for i := 0; i <= maxDepth; i++ {
+		v = []any{v}
+	}
+	for _, c := range binaryCodecs {
+		if _, err := c.Marshal(v); err == nil || !strings.Contains(err.Error(), errMaxDepth.Error()) {
+			t.Errorf("%s: marshal deep value: %v", c.ContentType(), err)
+		}
+	}
+
+	nested := strings.Repeat("\x91", maxDepth+1) + "\x01"
+	var out any
+	if err := (msgpackCodec{}).Unmarshal([]byte(nested), &out); err == nil {
+		t.Error("decoded value nested too deep")
+	}
+}
+
+func FuzzCodecDecode(f *testing.F) {
+	f1 := 2.5
+	for _, c := range binaryCodecs {
+		b, _ := c.Marshal(codecRecord{Name: "a", Ptr: &f1, Tags: []string{"t"}, Counts: map[string]uint16{"c": 1},
+			Children: []*codecRecord{{Name: "b"}}})
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		for _, c := range binaryCodecs {
+			var r codecRecord
+			if err := c.Unmarshal(b, &r); err != nil {
+				continue
+			}
+			if _, err := c.Marshal(r); err != nil {
+				t.Fatalf("%s: encode %+v decoded from %x: %v", c.ContentType(), r, b, err)
+			}
+		}
+	})
+}
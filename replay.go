@@ -0,0 +1,52 @@
+package restreq
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// FromHTTPRequest creates request with URL, headers, cookies and body
+// of req, so it can be modified and sent again with Do(req.Method).
+// Relative URL of inbound requests is resolved with req.Host.
+// Body of req is read and replaced, so req can still be used.
+// Context of req is not copied.
+func FromHTTPRequest(req *http.Request) (*Request, error) {
+	u := *req.URL
+	if !u.IsAbs() {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+		u.Host = req.Host
+	}
+
+	r := New(u.String())
+
+	for k, v := range req.Header {
+		switch k {
+		case "Cookie", "Content-Length":
+			continue
+		}
+		r.headers[k] = strings.Join(v, ", ")
+	}
+
+	for _, c := range req.Cookies() {
+		r.cookies[c.Name] = c
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		r.rawBody = []byte{}
+		return r, nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	r.rawBody = b
+	return r, nil
+}
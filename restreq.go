@@ -1,11 +1,15 @@
 package restreq
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -19,9 +23,20 @@ type httpClient interface {
 // http.Response.Body is an exception. You cannot use it, because
 // content of http.Response.Body is copied to Response.Body.
 // You don't have to call http.Response.Body.Close()
+//
+// Route is the name of route chosen by SetRouter.
 type Response struct {
 	*http.Response
-	Body []byte
+	Body     []byte
+	Route    string
+	streamed bool
+	envelope *envelope
+	sniff    bool
+	timings  *Timings
+	decode   DecodeOptions
+	codec    Codec
+
+	annotations map[any]any
 }
 
 // Header returns header
@@ -29,47 +44,220 @@ func (r *Response) Header(s string) string {
 	return r.Response.Header.Get(s)
 }
 
+// Allow returns methods listed in Allow header.
+func (r *Response) Allow() []string {
+	var methods []string
+	for _, v := range r.Response.Header.Values("Allow") {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods
+}
+
 // DecodeJSON decodes JSON
 func (r *Response) DecodeJSON(s any) error {
-	return json.Unmarshal(r.Body, &s)
+	if ok, err := r.empty(s); ok {
+		return err
+	}
+	defer r.observeDecode(time.Now())
+	b := r.Body
+	if r.envelope != nil {
+		var err error
+		if b, err = r.envelope.unwrap(b, r.StatusCode); err != nil {
+			return err
+		}
+	}
+	return r.unmarshalJSON(b, &s)
 }
 
 type requester interface {
+	seqRequester
+
 	Context(context.Context) requester
 	SetHTTPClient(httpClient) requester
 	AddHeader(string, string) requester
 	AddCookie(*http.Cookie) requester
 	AddJSONKeyValue(string, any) requester
+	AddJSONField(string) requester
+	SetPath(segments ...string) requester
+	AddQueryParam(key, value string) requester
+	SetQueryParams(map[string]string) requester
+	AddQueryParamAny(key string, value any) requester
+	SetQueryStruct(any) requester
 	SetTimeoutSec(int) requester
+	SetRetryCount(int) requester
+	SetRetryWaitTime(time.Duration) requester
+	SetRetryMaxWaitTime(time.Duration) requester
+	SetRetryCondition(func(*Response, error) bool) requester
+	SetRetryBackoff(Backoff) requester
+	SetRetryState(*RetryState) requester
+	OnRetryState(func(RetryState) error) requester
+	WithHedging(time.Duration, int) requester
+	OnHedgeLost(func(*http.Request, error)) requester
 	SetUserAgent(string) requester
+	SetRequestPriority(urgency int, incremental bool) requester
+	SetIfUnmodifiedSince(time.Time) requester
 	SetContentType(string) requester
 	SetContentTypeJSON() requester
 	SetJSONPayload(any) requester
+	SetJSONArrayStream(<-chan any) requester
+	SetXMLPayload(any) requester
+	SetMsgPackPayload(any) requester
+	SetCBORPayload(any) requester
+	SetGraphQLQuery(query string, variables map[string]any) requester
+	SetJSONRPC(method string, params any, id any) requester
+	SniffContentType() requester
+	SetMethodFallback(map[string]string) requester
+	FallbackOnError(func(req *Request, err error) (*Response, error)) requester
+	SetBasicAuthSecret(store CredentialStore, username, name string) requester
+	SetBearerToken(string) requester
+	SetAuthScheme(scheme, credentials string) requester
+	SetTokenProvider(TokenProvider) requester
+	SetDigestAuth(username, password string) requester
+	SetAPIKey(name, value string, in APIKeyLocation) requester
+	EnableProfiling() requester
+	UseNumber() requester
+	SetDecodeOptions(DecodeOptions) requester
+	UseCodec(Codec) requester
+	SetContentDigest(algs ...string) requester
+	SetReprDigest(algs ...string) requester
+	VerifyDigest() requester
+	SignMessage(*MessageSigner) requester
+	SetPayload(contentType string, p any) requester
+	SetBody([]byte) requester
+	SetBodyString(string) requester
+	SetBodyFile(fsys fs.FS, name string) requester
+	SetBodyReader(io.Reader) requester
+	SetContentLength(int64) requester
+	SetFormPayload(url.Values) requester
+	AddFormField(key, value string) requester
+	AddMultipartField(name, value string) requester
+	AddMultipartFile(fieldName string, fsys fs.FS, name string) requester
+	AddFile(fieldName, fileName string, r io.Reader) requester
 	SetBasicAuth(username, password string) requester
 	Debug(*log.Logger, DebugFlag) requester
 	WithBodyReader() requester
+	WithoutBody() requester
+	ForceBody() requester
+	SetAuditSink(AuditSink) requester
+	SetScrubber(*Scrubber) requester
+	SetRouter(Router) requester
+	SetRateLimiter(RateLimiter) requester
+	SetAPIVersion(string, VersionLocation) requester
+	SetResponseEnvelope(dataPath, errorPath string) requester
+	OnDeprecation(func(string, Deprecation)) requester
+	OnBeforeRequest(func(*http.Request) error) requester
+	OnAfterResponse(func(*Response) error) requester
+	AddErrorDetector(ErrorDetector) requester
+	AddInvariant(func(*Response) error) requester
+	OnViolation(func(string, error)) requester
+	WithAnnotation(key, value any) requester
+	SetVars(*Vars) requester
+	Extract(map[string]string) requester
+	SetRequestCompression(string) requester
+	OnUploadProgress(func(Progress)) requester
+	NegotiateEncoding() requester
+	OnRedirect(func(prev *http.Request, next *url.URL) error) requester
 	Post() (*Response, error)
 	Put() (*Response, error)
 	Patch() (*Response, error)
 	Get() (*Response, error)
 	Delete() (*Response, error)
+	Head() (*Response, error)
+	Options() (*Response, error)
+	Do(method string) (*Response, error)
+	Build(method string) (*http.Request, error)
+	DownloadParallel(path string, chunks int) error
+	Events(func(Event) error) error
 }
 
 // Request contains all methods to operate on REST API
 type Request struct {
+	err         error
 	ctx         context.Context
 	timeout     time.Duration
 	url         string
 	json        map[string]any
+	path        []string
+	query       url.Values
 	headers     map[string]string
 	cookies     map[string]*http.Cookie
 	username    string
 	password    string
+	secretAuth  *secretAuth
+	bearer      TokenProvider
+	digest      *digestAuth
+	signer      Signer
 	jsonPayload []byte
+	defaults    map[string]any
+	form        url.Values
+	bodySource  io.Reader
+	bodyFile    *fsFile
+	rawBody     []byte
+	bodyMode    int8
+	parts       []multipartPart
+	boundary    string
 	client      httpClient
 	debugFlags  int32
 	logger      *log.Logger
 	bodyReader  bool
+
+	contentLength int64
+	onRedirect    func(prev *http.Request, next *url.URL) error
+	compression   string
+	onProgress    func(Progress)
+	negotiate     bool
+	jsonStream    <-chan any
+	vars          *Vars
+	clientVars    *Vars
+	extract       map[string]string
+	stats         *latencyStats
+	tlsSession    *tlsSession
+	inflight      *inflight
+	endpoint      string
+	auditSink     AuditSink
+	fixtureDir    string
+	scrubber      *Scrubber
+	router        Router
+	envelope      *envelope
+	sniff         bool
+	profiling     bool
+	decode        DecodeOptions
+	codec         Codec
+	contentDigest []string
+	reprDigest    []string
+	verifyDigest  bool
+	rateLimiter   func(host string) RateLimiter
+	annotations   map[any]any
+	quirks        func(u string) *hostQuirks
+
+	apiVersion   string
+	apiVersionIn VersionLocation
+	baseURL      string
+
+	beforeHooks    []func(*http.Request) error
+	afterHooks     []func(*Response) error
+	errorDetectors []ErrorDetector
+	onDeprecation  func(string, Deprecation)
+	invariants     []func(*Response) error
+	onViolation    func(string, error)
+
+	retryCount     int
+	retryWait      time.Duration
+	retryMaxWait   time.Duration
+	retryCondition func(*Response, error) bool
+	retryBackoff   Backoff
+	retryState     *RetryState
+	onRetryState   func(RetryState) error
+	methodFallback map[string]string
+	errorFallback  func(*Request, error) (*Response, error)
+
+	hedgeDelay  time.Duration
+	hedgeMax    int
+	onHedgeLost func(*http.Request, error)
 }
 
 func New(u string) *Request {
@@ -81,6 +269,64 @@ func New(u string) *Request {
 	}
 }
 
+// clone returns copy of the request, which can be modified
+// without affecting the original one.
+func (r *Request) clone() *Request {
+	c := *r
+
+	c.json = make(map[string]any, len(r.json))
+	for k, v := range r.json {
+		c.json[k] = copyPathValue(v)
+	}
+
+	c.headers = make(map[string]string, len(r.headers))
+	for k, v := range r.headers {
+		c.headers[k] = v
+	}
+
+	if r.form != nil {
+		c.form = make(url.Values, len(r.form))
+		for k, v := range r.form {
+			c.form[k] = append([]string(nil), v...)
+		}
+	}
+
+	if r.query != nil {
+		c.query = make(url.Values, len(r.query))
+		for k, v := range r.query {
+			c.query[k] = append([]string(nil), v...)
+		}
+	}
+
+	c.cookies = make(map[string]*http.Cookie, len(r.cookies))
+	for k, v := range r.cookies {
+		c.cookies[k] = v
+	}
+
+	c.path = append([]string(nil), r.path...)
+	c.parts = append([]multipartPart(nil), r.parts...)
+	c.beforeHooks = append([]func(*http.Request) error(nil), r.beforeHooks...)
+	c.afterHooks = append([]func(*Response) error(nil), r.afterHooks...)
+	c.errorDetectors = append([]ErrorDetector(nil), r.errorDetectors...)
+	c.invariants = append([]func(*Response) error(nil), r.invariants...)
+
+	if r.annotations != nil {
+		c.annotations = make(map[any]any, len(r.annotations))
+		for k, v := range r.annotations {
+			c.annotations[k] = v
+		}
+	}
+
+	if r.extract != nil {
+		c.extract = make(map[string]string, len(r.extract))
+		for k, v := range r.extract {
+			c.extract[k] = v
+		}
+	}
+
+	return &c
+}
+
 type DebugFlag int32
 
 // DebugFlags to control logger behavior.
@@ -106,6 +352,24 @@ func (r *Request) WithBodyReader() requester {
 	return r
 }
 
+// SetRequestCompression compresses request body with registered
+// compressor, e.g. gzip, and sets Content-Encoding. Content-Length
+// is the length of the compressed body, streamed body is sent chunked.
+func (r *Request) SetRequestCompression(name string) requester {
+	r.compression = name
+	return r
+}
+
+// OnRedirect sets callback invoked before following every redirect.
+// prev is the request that got the redirect response, next is the target
+// URL and can be modified in place (e.g. to force https).
+// Returning an error stops redirects and the error is returned by the request.
+// The callback is not used with an external http client.
+func (r *Request) OnRedirect(f func(prev *http.Request, next *url.URL) error) requester {
+	r.onRedirect = f
+	return r
+}
+
 // SetHTTPClient sets external http client.
 func (r *Request) SetHTTPClient(c httpClient) requester {
 	r.client = c
@@ -122,14 +386,23 @@ func (r *Request) Debug(logger *log.Logger, flags DebugFlag) requester {
 
 // SetJSONPayload encodes map or struct to json byte array.
 func (r *Request) SetJSONPayload(p any) requester {
-	w := bytes.NewBuffer([]byte{})
-	json.NewEncoder(w).Encode(p)
-	r.jsonPayload = w.Bytes()
+	p, err := r.withDefaults(p)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	b, err := r.marshalJSON(p)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	r.jsonPayload = append(b, '\n')
 	return r
 }
 
 // SetBasicAuth sets basic auth with username and password.
 func (r *Request) SetBasicAuth(username, password string) requester {
+	r.clearAuth()
 	r.username = username
 	r.password = password
 	return r
@@ -180,15 +453,69 @@ func (r *Request) AddHeader(k string, v string) requester {
 // AddJSONKeyValue converts KV to json byte array.
 // You can add many KV, they will be added to the map
 // and converted to an byte array when the request is sent.
+// Key can be a path like user.address.city or tags[0],
+// which builds nested objects and arrays.
 func (r *Request) AddJSONKeyValue(key string, value any) requester {
 	if key == "" || value == "" {
 		return r
 	}
 
-	r.json[key] = value
+	r.setJSONKey(key, value)
 	return r
 }
 
+// AddJSONField adds JSON field in HTTPie syntax: key=value sets
+// string, key:=value sets raw JSON value, like count:=1, ok:=true
+// or tags:=["a"]. Key can be a path as in AddJSONKeyValue.
+func (r *Request) AddJSONField(s string) requester {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 || i == 1 && s[0] == ':' {
+		r.setErr(fmt.Errorf("json field %q: expected key=value or key:=value", s))
+		return r
+	}
+
+	key, value := s[:i], s[i+1:]
+	if !strings.HasSuffix(key, ":") {
+		r.setJSONKey(key, value)
+		return r
+	}
+	key = key[:len(key)-1]
+
+	if !json.Valid([]byte(value)) {
+		r.setErr(fmt.Errorf("json field %q: invalid JSON value", s))
+		return r
+	}
+	var v any
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		r.setErr(fmt.Errorf("json field %q: %w", s, err))
+		return r
+	}
+	r.setJSONKey(key, v)
+	return r
+}
+
+func (r *Request) setJSONKey(key string, value any) {
+	if !strings.ContainsAny(key, ".[") {
+		r.json[key] = value
+		return
+	}
+
+	keys, err := parsePath(key)
+	if err == nil {
+		err = setPath(r.json, keys, value)
+	}
+	if err != nil {
+		r.setErr(fmt.Errorf("json key %q: %w", key, err))
+	}
+}
+
+// Do executes any method, also nonstandard ones like PURGE or PROPFIND.
+func (r *Request) Do(method string) (*Response, error) {
+	return r.do(method)
+}
+
 // Post executes the post method
 func (r *Request) Post() (*Response, error) {
 	return r.do(http.MethodPost)
@@ -209,13 +536,25 @@ func (r *Request) Patch() (*Response, error) {
 	return r.do(http.MethodPatch)
 }
 
+// Head executes the head method. The response has no body,
+// use it to check status and headers, e.g. Content-Length.
+func (r *Request) Head() (*Response, error) {
+	return r.do(http.MethodHead)
+}
+
+// Options executes the options method, e.g. for CORS preflight
+// or checking allowed methods with Response.Allow.
+func (r *Request) Options() (*Response, error) {
+	return r.do(http.MethodOptions)
+}
+
 // Put executes the put method
 func (r *Request) Put() (*Response, error) {
 	return r.do(http.MethodPut)
 }
 
 func (r *Request) debug(f DebugFlag, s string) {
-	if r.logger == nil || r.debugFlags&(1<<(f-1)) == 0 {
+	if r.logger == nil || r.debugFlags&int32(f) == 0 {
 		return
 	}
 
@@ -0,0 +1,49 @@
+package restreq
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugFlags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Resp", "r1")
+		w.Write([]byte("resp body"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		flags DebugFlag
+		want  []string
+		skip  []string
+	}{
+		{ReqBody, []string{"req body"}, []string{"X-Req", "X-Resp", "resp body"}},
+		{ReqHeaders | RespHeaders, []string{"X-Req: q1", "X-Resp: r1"}, []string{"req body", "resp body"}},
+		{RespBody, []string{"resp body"}, []string{"req body", "X-Req", "X-Resp"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		_, err := New(srv.URL).
+			AddHeader("X-Req", "q1").
+			SetBodyString("req body").
+			Debug(log.New(&out, "", 0), tt.flags).
+			Post()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(out.String(), s) {
+				t.Errorf("flags %b: %q not logged in %q", tt.flags, s, out.String())
+			}
+		}
+		for _, s := range tt.skip {
+			if strings.Contains(out.String(), s) {
+				t.Errorf("flags %b: %q logged in %q", tt.flags, s, out.String())
+			}
+		}
+	}
+}
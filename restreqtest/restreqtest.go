@@ -0,0 +1,100 @@
+/*
+Package restreqtest serves responses recorded with Client.RecordFixtures
+from http.RoundTripper, so tests run with realistic data and without
+network.
+
+	// once, against live API
+	c := restreq.NewClient("https://api.example.com").
+		RecordFixtures("testdata/fixtures")
+
+	// in tests
+	tr, err := restreqtest.LoadFixtures("testdata/fixtures")
+	c := restreq.NewClient("https://api.example.com").SetTransport(tr)
+*/
+package restreqtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/scootpl/restreq"
+)
+
+// Transport serves fixtures by method and path of request.
+// Query is ignored.
+type Transport struct {
+	mu       sync.RWMutex
+	fixtures map[string]restreq.Fixture
+}
+
+// NewTransport creates transport serving fixtures.
+func NewTransport(fixtures ...restreq.Fixture) *Transport {
+	t := &Transport{fixtures: make(map[string]restreq.Fixture)}
+	for _, f := range fixtures {
+		t.Add(f)
+	}
+	return t
+}
+
+// LoadFixtures creates transport serving fixtures from .json files in dir.
+func LoadFixtures(dir string) (*Transport, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewTransport()
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var f restreq.Fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("restreqtest: %s: %w", name, err)
+		}
+		t.Add(f)
+	}
+	return t, nil
+}
+
+// Add adds fixture, replacing fixture of the same method and path.
+func (t *Transport) Add(f restreq.Fixture) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fixtures[f.Method+" "+f.Path] = f
+}
+
+// RoundTrip returns response of fixture matching req,
+// or error if there is none.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	t.mu.RLock()
+	f, ok := t.fixtures[req.Method+" "+req.URL.Path]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("restreqtest: no fixture for %s %s", req.Method, req.URL.Path)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package restreq
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retry waits.
+const (
+	DefaultRetryWaitTime    = 100 * time.Millisecond
+	DefaultRetryMaxWaitTime = 2 * time.Second
+)
+
+// SetRetryCount sets number of retries after network errors,
+// 429 and 5xx responses. Requests with streamed body, e.g. files, are not retried.
+func (r *Request) SetRetryCount(n int) requester {
+	r.retryCount = n
+	return r
+}
+
+// SetRetryWaitTime sets wait before the first retry. Next waits are
+// doubled with random jitter.
+func (r *Request) SetRetryWaitTime(d time.Duration) requester {
+	r.retryWait = d
+	return r
+}
+
+// SetRetryMaxWaitTime sets maximum wait between retries,
+// also limiting Retry-After of the response.
+func (r *Request) SetRetryMaxWaitTime(d time.Duration) requester {
+	r.retryMaxWait = d
+	return r
+}
+
+// SetRetryCondition sets function deciding whether to retry,
+// instead of retrying network errors, 429 and 5xx responses.
+// Response is nil on network errors.
+func (r *Request) SetRetryCondition(f func(*Response, error) bool) requester {
+	r.retryCondition = f
+	return r
+}
+
+// SetRetryCondition sets function deciding whether to retry
+// every request.
+func (c *Client) SetRetryCondition(f func(*Response, error) bool) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryCondition = f
+	return c
+}
+
+// SetRetryCount sets number of retries of every request.
+func (c *Client) SetRetryCount(n int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryCount = n
+	return c
+}
+
+// SetRetryWaitTime sets wait before the first retry of every request.
+func (c *Client) SetRetryWaitTime(d time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryWait = d
+	return c
+}
+
+// SetRetryMaxWaitTime sets maximum wait between retries of every request.
+func (c *Client) SetRetryMaxWaitTime(d time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryMaxWait = d
+	return c
+}
+
+func (r *Request) shouldRetry(resp *Response, err error) bool {
+	if r.ctx != nil && r.ctx.Err() != nil || errors.Is(err, ErrCanceledAll) {
+		return false
+	}
+	if r.retryCondition != nil {
+		return r.retryCondition(resp, err)
+	}
+	if resp == nil {
+		return err != nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryDelay returns wait before retry. Retry-After of 429 and 503
+// responses is used instead of backoff.
+func (r *Request) retryDelay(attempt int, prev time.Duration, resp *Response) time.Duration {
+	if d, ok := r.retryAfter(resp); ok {
+		return d
+	}
+	return r.backoff(attempt, prev)
+}
+
+// retryAfter returns wait from Retry-After header, seconds or HTTP-date,
+// limited to max retry wait.
+func (r *Request) retryAfter(resp *Response) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+
+	v := strings.TrimSpace(resp.Header("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+
+	var d time.Duration
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		d = time.Duration(sec) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = time.Until(t)
+	} else {
+		return 0, false
+	}
+
+	max := r.retryMaxWait
+	if max <= 0 {
+		max = DefaultRetryMaxWaitTime
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > max {
+		d = max
+	}
+	return d, true
+}
+
+// backoff returns wait before retry with the backoff strategy
+// of the request.
+func (r *Request) backoff(attempt int, prev time.Duration) time.Duration {
+	b := r.retryBackoff
+	if b == nil {
+		b = EqualJitter(r.retryWait, r.retryMaxWait)
+	}
+	return b(attempt, prev)
+}
@@ -0,0 +1,90 @@
+package restreq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// RetryState is retry progress of a request. It can be persisted, e.g. as
+// JSON, so a restarted worker resumes the retry schedule instead of
+// starting over.
+type RetryState struct {
+	Attempt        int       `json:"attempt"`
+	NextAttempt    time.Time `json:"next_attempt"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+}
+
+// SetRetryState resumes retries from s, which is updated after every
+// attempt. The request waits until s.NextAttempt and its retry count
+// includes attempts made before. IdempotencyKey is generated if empty
+// and sent as Idempotency-Key header, unless the header is set.
+func (r *Request) SetRetryState(s *RetryState) requester {
+	r.retryState = s
+	return r
+}
+
+// OnRetryState sets callback invoked with updated retry state
+// before waiting for retry, so it can be persisted.
+// An error stops retries and is returned by the request.
+func (r *Request) OnRetryState(f func(RetryState) error) requester {
+	r.onRetryState = f
+	return r
+}
+
+// resumeRetry prepares retry state and waits until the next attempt.
+func (r *Request) resumeRetry() (int, error) {
+	s := r.retryState
+	if s.IdempotencyKey == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return 0, err
+		}
+		s.IdempotencyKey = hex.EncodeToString(b)
+	}
+
+	if d := time.Until(s.NextAttempt); d > 0 {
+		if err := r.sleep(d); err != nil {
+			return 0, err
+		}
+	}
+	return s.Attempt, nil
+}
+
+// updateRetry updates retry state after attempt.
+func (r *Request) updateRetry(attempt int, wait time.Duration) {
+	if r.retryState == nil {
+		return
+	}
+	r.retryState.Attempt = attempt + 1
+	r.retryState.NextAttempt = time.Now().Add(wait)
+}
+
+// saveRetry updates retry state before waiting for retry
+// and passes it to OnRetryState callback.
+func (r *Request) saveRetry(attempt int, wait time.Duration) error {
+	r.updateRetry(attempt, wait)
+	if r.retryState != nil && r.onRetryState != nil {
+		return r.onRetryState(*r.retryState)
+	}
+	return nil
+}
+
+// sleep waits d, unless request context is done.
+func (r *Request) sleep(d time.Duration) error {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
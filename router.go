@@ -0,0 +1,75 @@
+package restreq
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Router can rewrite destination of the request, modifying req.URL,
+// and returns name of the chosen route, available as Response.Route.
+type Router func(req *http.Request) (string, error)
+
+// SetRouter sets router called before OnBeforeRequest hooks.
+func (r *Request) SetRouter(f Router) requester {
+	r.router = f
+	return r
+}
+
+// SetRouter sets router of every request.
+func (c *Client) SetRouter(f Router) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.router = f
+	return c
+}
+
+// CanaryRouter sends percent of requests to target, replacing scheme
+// and host, and prefixing path with path of target. These requests
+// get route "canary", other requests route "default".
+func CanaryRouter(percent float64, target string) Router {
+	return func(req *http.Request) (string, error) {
+		if rand.Float64()*100 >= percent {
+			return "default", nil
+		}
+
+		t, err := url.Parse(target)
+		if err != nil {
+			return "", err
+		}
+
+		req.URL.Scheme = t.Scheme
+		req.URL.Host = t.Host
+		if p := strings.TrimRight(t.Path, "/"); p != "" {
+			req.URL.Path = p + req.URL.Path
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = p + req.URL.RawPath
+			}
+		}
+		return "canary", nil
+	}
+}
+
+type routeKey struct{}
+
+// route calls router and returns request with the chosen route.
+func (r *Request) route(req *http.Request) (*http.Request, error) {
+	host := req.URL.Host
+
+	name, err := r.router(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL.Host != host {
+		req.Host = req.URL.Host
+	}
+	return req.WithContext(context.WithValue(req.Context(), routeKey{}, name)), nil
+}
+
+func routeName(req *http.Request) string {
+	s, _ := req.Context().Value(routeKey{}).(string)
+	return s
+}
@@ -0,0 +1,339 @@
+/*
+Package s3 has thin helpers for objects of S3-compatible storage,
+built on restreq. Requests are signed by the client, e.g. with
+SetAWSSigV4, and retried with its retry settings.
+
+	c := restreq.NewClient("").
+		SetAWSSigV4("eu-west-1", "s3", creds).
+		SetRetryCount(3)
+	b := s3.New(c, "https://s3.eu-west-1.amazonaws.com", "my-bucket")
+
+	err := b.Put(ctx, "reports/1.json", data, "application/json")
+
+	err = b.Upload(ctx, "videos/big.mp4", f, "video/mp4")
+
+	err = b.List(ctx, "reports/", func(o s3.Object) error {
+		log.Printf("%s %d", o.Key, o.Size)
+		return nil
+	})
+
+Objects are addressed path-style, endpoint/bucket/key.
+*/
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scootpl/restreq"
+)
+
+// MinPartSize is the smallest part of multipart upload, except the last.
+const MinPartSize = 5 << 20
+
+// Error is error response of the server.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("s3: %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// Object is an object returned by List.
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// Bucket makes requests to objects of a bucket.
+type Bucket struct {
+	client   *restreq.Client
+	url      string
+	partSize int64
+}
+
+// New creates bucket at endpoint. Requests are created by client,
+// if not nil, so they are signed and retried with its settings.
+func New(client *restreq.Client, endpoint, bucket string) *Bucket {
+	return &Bucket{
+		client:   client,
+		url:      strings.TrimRight(endpoint, "/") + "/" + escape(bucket),
+		partSize: 8 << 20,
+	}
+}
+
+// SetPartSize sets size of parts of Upload, at least MinPartSize.
+func (b *Bucket) SetPartSize(n int64) *Bucket {
+	if n < MinPartSize {
+		n = MinPartSize
+	}
+	b.partSize = n
+	return b
+}
+
+// Put stores body as object key.
+func (b *Bucket) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	r := b.request(ctx, key, "")
+	if contentType != "" {
+		r.SetContentType(contentType)
+	}
+	resp, err := r.SetBody(body).Put()
+	return check(resp, err, http.StatusOK)
+}
+
+// Get returns body of object key, which must be closed.
+func (b *Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := b.request(ctx, key, "").WithBodyReader().Get()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Response.Body.Close()
+		body, _ := io.ReadAll(resp.Response.Body)
+		return nil, responseError(resp.StatusCode, body)
+	}
+	return resp.Response.Body, nil
+}
+
+// Delete removes object key. Missing object is no error.
+func (b *Bucket) Delete(ctx context.Context, key string) error {
+	resp, err := b.request(ctx, key, "").Delete()
+	return check(resp, err, http.StatusNoContent, http.StatusOK)
+}
+
+type listResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		ETag         string
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List calls f for objects with key starting with prefix, in key order.
+// Pages are fetched with continuation tokens as f consumes them.
+// An error returned by f stops listing and is returned.
+func (b *Bucket) List(ctx context.Context, prefix string, f func(Object) error) error {
+	var token string
+	for {
+		q := "list-type=2"
+		if prefix != "" {
+			q += "&prefix=" + escape(prefix)
+		}
+		if token != "" {
+			q += "&continuation-token=" + escape(token)
+		}
+
+		resp, err := b.request(ctx, "", q).Get()
+		if err := check(resp, err, http.StatusOK); err != nil {
+			return err
+		}
+
+		var page listResult
+		if err := resp.DecodeXML(&page); err != nil {
+			return err
+		}
+		for _, c := range page.Contents {
+			if err := f(Object(c)); err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+type completePart struct {
+	PartNumber int
+	ETag       string
+}
+
+// Upload stores r as object key. Body shorter than the part size is
+// sent with Put, longer one with multipart upload of parts read to
+// memory one by one, so every part is signed and can be retried.
+// Multipart upload is aborted if it fails.
+func (b *Bucket) Upload(ctx context.Context, key string, r io.Reader, contentType string) error {
+	buf := make([]byte, b.partSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return b.Put(ctx, key, buf[:n], contentType)
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := b.createUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := b.uploadParts(ctx, key, id, buf, r); err != nil {
+		b.abortUpload(context.Background(), key, id)
+		return err
+	}
+	return nil
+}
+
+// uploadParts sends buf, which is full first part, and the rest of r
+// as parts of upload id and completes the upload.
+func (b *Bucket) uploadParts(ctx context.Context, key, id string, buf []byte, r io.Reader) error {
+	var parts []completePart
+	for n := len(buf); n > 0; {
+		num := len(parts) + 1
+		etag, err := b.uploadPart(ctx, key, id, num, buf[:n])
+		if err != nil {
+			return err
+		}
+		parts = append(parts, completePart{PartNumber: num, ETag: etag})
+
+		if n, err = io.ReadFull(r, buf); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+		Parts   []completePart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := b.request(ctx, key, "uploadId="+escape(id)).
+		SetContentType("application/xml").
+		SetBody(body).
+		Post()
+	if err := check(resp, err, http.StatusOK); err != nil {
+		return err
+	}
+	// Completion can fail after status 200 was sent.
+	if bytes.Contains(resp.Body, []byte("<Error>")) {
+		return responseError(resp.StatusCode, resp.Body)
+	}
+	return nil
+}
+
+// uploadPart sends part num of upload id and returns its ETag.
+func (b *Bucket) uploadPart(ctx context.Context, key, id string, num int, part []byte) (string, error) {
+	resp, err := b.request(ctx, key, "partNumber="+strconv.Itoa(num)+"&uploadId="+escape(id)).
+		SetBody(part).
+		Put()
+	if err := check(resp, err, http.StatusOK); err != nil {
+		return "", err
+	}
+	return resp.Header("ETag"), nil
+}
+
+func (b *Bucket) createUpload(ctx context.Context, key, contentType string) (string, error) {
+	r := b.request(ctx, key, "uploads")
+	if contentType != "" {
+		r.SetContentType(contentType)
+	}
+	resp, err := r.Post()
+	if err := check(resp, err, http.StatusOK); err != nil {
+		return "", err
+	}
+
+	var res struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := resp.DecodeXML(&res); err != nil {
+		return "", err
+	}
+	if res.UploadID == "" {
+		return "", errors.New("s3: missing upload id")
+	}
+	return res.UploadID, nil
+}
+
+func (b *Bucket) abortUpload(ctx context.Context, key, id string) {
+	b.request(ctx, key, "uploadId="+escape(id)).Delete()
+}
+
+// request creates request to key, or the bucket if key is empty,
+// with query already escaped.
+func (b *Bucket) request(ctx context.Context, key, query string) *restreq.Request {
+	u := b.url
+	if key != "" {
+		u += "/" + escapeKey(key)
+	}
+	if query != "" {
+		u += "?" + query
+	}
+
+	var r *restreq.Request
+	if b.client != nil {
+		r = b.client.New(u)
+	} else {
+		r = restreq.New(u)
+	}
+	r.Context(ctx)
+	return r
+}
+
+// check returns err or error of resp with status other than ok.
+func check(resp *restreq.Response, err error, ok ...int) error {
+	if err != nil {
+		return err
+	}
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	return responseError(resp.StatusCode, resp.Body)
+}
+
+// responseError returns error of response with status and body.
+func responseError(status int, body []byte) error {
+	e := &Error{StatusCode: status}
+	xml.Unmarshal(body, e)
+	if e.Code == "" {
+		e.Code = http.StatusText(status)
+	}
+	return e
+}
+
+// escapeKey escapes segments of key, keeping slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes all but unreserved characters, as S3
+// does for signing.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
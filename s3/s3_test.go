@@ -0,0 +1,215 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/scootpl/restreq"
+)
+
+// server is an in-memory bucket named b, listing two keys per page.
+type server struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	parts    map[string][]byte
+	aborted  bool
+	failPart bool
+	// completeErr makes completion fail after status 200.
+	completeErr bool
+}
+
+func newServer() *server {
+	return &server{objects: map[string][]byte{}, parts: map[string][]byte{}}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/b/")
+	q := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+
+	switch {
+	case r.URL.Path == "/b" && q.Get("list-type") == "2":
+		s.list(w, q.Get("prefix"), q.Get("continuation-token"))
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>u1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == http.MethodPut && q.Get("uploadId") == "u1":
+		if s.failPart && q.Get("partNumber") == "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "<Error><Code>EntityTooSmall</Code><Message>too small</Message></Error>")
+			return
+		}
+		s.parts[q.Get("partNumber")] = body
+		w.Header().Set("ETag", `"etag`+q.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && q.Get("uploadId") == "u1":
+		if s.completeErr {
+			fmt.Fprint(w, "<Error><Code>InternalError</Code></Error>")
+			return
+		}
+		var c struct {
+			Parts []completePart `xml:"Part"`
+		}
+		xml.Unmarshal(body, &c)
+		var all []byte
+		for i, p := range c.Parts {
+			if p.PartNumber != i+1 || p.ETag != `"etag`+strconv.Itoa(i+1)+`"` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			all = append(all, s.parts[strconv.Itoa(p.PartNumber)]...)
+		}
+		s.objects[key] = all
+	case r.Method == http.MethodDelete && q.Get("uploadId") == "u1":
+		s.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[key] = body
+	case r.Method == http.MethodGet:
+		b, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			return
+		}
+		w.Write(b)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *server) list(w http.ResponseWriter, prefix, token string) {
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) && k > token {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	fmt.Fprint(w, "<ListBucketResult>")
+	for i, k := range keys {
+		if i == 2 {
+			fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[1])
+			break
+		}
+		fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2009-10-12T17:50:30.000Z</LastModified></Contents>", k, len(s.objects[k]))
+	}
+	fmt.Fprint(w, "</ListBucketResult>")
+}
+
+func testBucket(t *testing.T, s *server) *Bucket {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	c := restreq.NewClient("").SetAWSSigV4("us-east-1", "s3", restreq.AWSCredentials{AccessKeyID: "id", SecretAccessKey: "secret"})
+	return New(c, srv.URL, "b").SetPartSize(MinPartSize)
+}
+
+func TestObject(t *testing.T) {
+	s := newServer()
+	b := testBucket(t, s)
+	ctx := context.Background()
+
+	key := "dir/a b+c.txt"
+	if err := b.Put(ctx, key, []byte("hello"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.objects[key]; !ok {
+		t.Fatalf("stored keys %v", s.objects)
+	}
+
+	rc, err := b.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "hello" {
+		t.Errorf("Get = %q", got)
+	}
+
+	if err := b.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	var e *Error
+	if _, err := b.Get(ctx, key); !errors.As(err, &e) || e.Code != "NoSuchKey" || e.StatusCode != http.StatusNotFound {
+		t.Errorf("Get deleted = %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	s := newServer()
+	for _, k := range []string{"a/1", "a/2", "a/3", "a/4", "a/5", "b/1"} {
+		s.objects[k] = []byte(k)
+	}
+	b := testBucket(t, s)
+
+	var keys []string
+	err := b.List(context.Background(), "a/", func(o Object) error {
+		if o.Size != 3 || o.LastModified.Year() != 2009 {
+			t.Errorf("object %+v", o)
+		}
+		keys = append(keys, o.Key)
+		return nil
+	})
+	if err != nil || strings.Join(keys, ",") != "a/1,a/2,a/3,a/4,a/5" {
+		t.Errorf("List = %v, %v", keys, err)
+	}
+
+	stop := errors.New("stop")
+	if err := b.List(context.Background(), "", func(Object) error { return stop }); err != stop {
+		t.Errorf("List stopped with %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), MinPartSize/10*2+7)
+	tests := []struct {
+		name    string
+		size    int
+		setup   func(*server)
+		parts   int
+		aborted bool
+	}{
+		{"single", 100, nil, 0, false},
+		{"multipart", len(data), nil, 3, false},
+		{"exact parts", 2 * MinPartSize, nil, 2, false},
+		{"part fails", len(data), func(s *server) { s.failPart = true }, 1, true},
+		{"complete fails", len(data), func(s *server) { s.completeErr = true }, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer()
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			b := testBucket(t, s)
+
+			err := b.Upload(context.Background(), "big", bytes.NewReader(data[:tt.size]), "application/octet-stream")
+			if (err != nil) != tt.aborted || s.aborted != tt.aborted || len(s.parts) != tt.parts {
+				t.Fatalf("Upload = %v, aborted %v, %d parts", err, s.aborted, len(s.parts))
+			}
+			if !tt.aborted && !bytes.Equal(s.objects["big"], data[:tt.size]) {
+				t.Errorf("stored %d bytes, want %d", len(s.objects["big"]), tt.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Scrubber removes sensitive data from debug logs and audit records.
+// It is applied in addition to the default redaction of audit records.
+type Scrubber struct {
+	headers  map[string]bool
+	cookies  map[string]bool
+	paths    [][]pathKey
+	patterns []*regexp.Regexp
+}
+
+// NewScrubber creates scrubber without rules.
+func NewScrubber() *Scrubber {
+	return &Scrubber{
+		headers: make(map[string]bool),
+		cookies: make(map[string]bool),
+	}
+}
+
+// AddHeader adds names of headers and query parameters,
+// which values are redacted.
+func (s *Scrubber) AddHeader(names ...string) *Scrubber {
+	for _, n := range names {
+		s.headers[strings.ToLower(n)] = true
+	}
+	return s
+}
+
+// AddCookie adds names of cookies, which values are redacted.
+func (s *Scrubber) AddCookie(names ...string) *Scrubber {
+	for _, n := range names {
+		s.cookies[n] = true
+	}
+	return s
+}
+
+// AddJSONPath adds paths of JSON body values, which are redacted.
+// Paths like user.card, items[*].token or *.password are supported.
+// Invalid paths are ignored.
+func (s *Scrubber) AddJSONPath(paths ...string) *Scrubber {
+	for _, p := range paths {
+		if keys, err := parsePath(p); err == nil {
+			s.paths = append(s.paths, keys)
+		}
+	}
+	return s
+}
+
+// AddPattern adds regular expression, which matches are redacted
+// in bodies, header values and URLs.
+func (s *Scrubber) AddPattern(re *regexp.Regexp) *Scrubber {
+	s.patterns = append(s.patterns, re)
+	return s
+}
+
+// Header returns scrubbed header value.
+func (s *Scrubber) Header(name, value string) string {
+	if s == nil {
+		return value
+	}
+	name = strings.ToLower(name)
+	if s.headers[name] {
+		return redacted
+	}
+	if name == "cookie" || name == "set-cookie" {
+		value = s.cookieHeader(value)
+	}
+	return s.text(value)
+}
+
+// cookieHeader redacts values of cookies in Cookie and Set-Cookie header.
+func (s *Scrubber) cookieHeader(v string) string {
+	parts := strings.Split(v, ";")
+	for i, p := range parts {
+		name, _, ok := strings.Cut(p, "=")
+		if ok && s.cookies[strings.TrimSpace(name)] {
+			parts[i] = name + "=" + redacted
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Cookie returns cookie with scrubbed value.
+func (s *Scrubber) Cookie(c *http.Cookie) *http.Cookie {
+	if s == nil || !s.cookies[c.Name] {
+		return c
+	}
+	x := *c
+	x.Value = redacted
+	return &x
+}
+
+// URL returns scrubbed URL.
+func (s *Scrubber) URL(u string) string {
+	if s == nil {
+		return u
+	}
+
+	if p, err := url.Parse(u); err == nil && p.RawQuery != "" {
+		q := p.Query()
+		for k := range q {
+			if s.headers[strings.ToLower(k)] {
+				q.Set(k, redacted)
+			}
+		}
+		p.RawQuery = q.Encode()
+		u = p.String()
+	}
+	return s.text(u)
+}
+
+// Body returns scrubbed body. JSON paths are applied only
+// to a valid JSON body.
+func (s *Scrubber) Body(b []byte) []byte {
+	if s == nil {
+		return b
+	}
+
+	if len(s.paths) > 0 {
+		var v any
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		if err := d.Decode(&v); err == nil {
+			for _, p := range s.paths {
+				v = scrubPath(v, p)
+			}
+			if x, err := json.Marshal(v); err == nil {
+				b = x
+			}
+		}
+	}
+
+	for _, re := range s.patterns {
+		b = re.ReplaceAll(b, []byte(redacted))
+	}
+	return b
+}
+
+func (s *Scrubber) text(v string) string {
+	for _, re := range s.patterns {
+		v = re.ReplaceAllString(v, redacted)
+	}
+	return v
+}
+
+func scrubPath(v any, keys []pathKey) any {
+	if len(keys) == 0 {
+		return redacted
+	}
+	k := keys[0]
+
+	switch t := v.(type) {
+	case map[string]any:
+		if k.index >= 0 || k.index == -2 {
+			return v
+		}
+		for name, x := range t {
+			if k.any || name == k.key {
+				t[name] = scrubPath(x, keys[1:])
+			}
+		}
+	case []any:
+		i := k.index
+		if i == -1 && !k.any {
+			n, err := strconv.Atoi(k.key)
+			if err != nil {
+				return v
+			}
+			i = n
+		}
+		for j, x := range t {
+			if k.any || j == i {
+				t[j] = scrubPath(x, keys[1:])
+			}
+		}
+	}
+	return v
+}
+
+// SetScrubber sets scrubber used by debug logging and audit.
+func (r *Request) SetScrubber(s *Scrubber) requester {
+	r.scrubber = s
+	return r
+}
+
+// SetScrubber sets scrubber used by debug logging and audit
+// of every request.
+func (c *Client) SetScrubber(s *Scrubber) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scrubber = s
+	return c
+}
@@ -0,0 +1,70 @@
+package restreq
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func testScrubber() *Scrubber {
+	return NewScrubber().
+		AddHeader("X-Api-Key", "access_token").
+		AddCookie("session").
+		AddJSONPath("user.card", "items[*].token", "*.password").
+		AddPattern(regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-\d{4}\b`))
+}
+
+func TestScrubberHeader(t *testing.T) {
+	s := testScrubber()
+	tests := []struct {
+		name, value, want string
+	}{
+		{"X-API-KEY", "k1", redacted},
+		{"X-Request-Id", "r1", "r1"},
+		{"Cookie", "session=s1; theme=dark", "session=" + redacted + "; theme=dark"},
+		{"Set-Cookie", "session=s1; Path=/; HttpOnly", "session=" + redacted + "; Path=/; HttpOnly"},
+		{"X-Card", "card 1234-5678-9012-3456", "card " + redacted},
+	}
+	for _, tt := range tests {
+		if got := s.Header(tt.name, tt.value); got != tt.want {
+			t.Errorf("Header(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+
+	c := &http.Cookie{Name: "session", Value: "s1"}
+	if got := s.Cookie(c); got.Value != redacted || c.Value != "s1" {
+		t.Errorf("Cookie = %v, original %v", got, c)
+	}
+	if got := (*Scrubber)(nil).Header("X-Api-Key", "k1"); got != "k1" {
+		t.Errorf("nil scrubber redacted %q", got)
+	}
+}
+
+func TestScrubberURL(t *testing.T) {
+	got := testScrubber().URL("https://example.com/cards/1234-5678-9012-3456?access_token=t1&page=2")
+	want := "https://example.com/cards/" + redacted + "?access_token=%5BREDACTED%5D&page=2"
+	if got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+}
+
+func TestScrubberBody(t *testing.T) {
+	s := testScrubber()
+	tests := []struct {
+		name, body, want string
+	}{
+		{
+			"json paths",
+			`{"user":{"card":"c1","name":"n"},"items":[{"token":"t1"},{"token":"t2","id":7}],"login":{"password":"p"}}`,
+			`{"items":[{"token":"[REDACTED]"},{"id":7,"token":"[REDACTED]"}],"login":{"password":"[REDACTED]"},"user":{"card":"[REDACTED]","name":"n"}}`,
+		},
+		{"large number kept", `{"id":12345678901234567890}`, `{"id":12345678901234567890}`},
+		{"pattern in json", `{"note":"1234-5678-9012-3456"}`, `{"note":"[REDACTED]"}`},
+		{"not json", `card=1234-5678-9012-3456&password=p`, `card=[REDACTED]&password=p`},
+	}
+	for _, tt := range tests {
+		if got := string(s.Body([]byte(tt.body))); got != tt.want {
+			t.Errorf("%s: Body = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
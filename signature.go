@@ -0,0 +1,671 @@
+package restreq
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithms of HTTP Message Signatures (RFC 9421).
+const (
+	SigRSAPSSSHA512    = "rsa-pss-sha512"
+	SigRSAv15SHA256    = "rsa-v1_5-sha256"
+	SigHMACSHA256      = "hmac-sha256"
+	SigECDSAP256SHA256 = "ecdsa-p256-sha256"
+	SigECDSAP384SHA384 = "ecdsa-p384-sha384"
+	SigEd25519         = "ed25519"
+)
+
+// ErrInvalidSignature is returned when message signature
+// does not verify.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// MessageSigner signs requests with HTTP Message Signatures (RFC 9421),
+// adding Signature-Input and Signature headers.
+type MessageSigner struct {
+	// Label of the signature, sig1 if empty.
+	Label string
+	KeyID string
+	// Alg is one of Sig* algorithms.
+	Alg string
+	// Key is []byte for HMAC, ed25519.PrivateKey, *ecdsa.PrivateKey
+	// or *rsa.PrivateKey.
+	Key any
+	// Components are derived components like @method and lowercase
+	// header names, or serialized identifiers with parameters like
+	// "content-type";sf or "@query-param";name="id". By default @method, @authority, @path, @query and
+	// content-digest, if the request has it, are signed.
+	Components []string
+	// Expires sets expires parameter, if not zero.
+	Expires time.Duration
+	// Tag sets tag parameter, if not empty.
+	Tag string
+}
+
+// SignMessage signs the request with s, after all headers are set.
+// Use SetContentDigest to cover the body.
+func (r *Request) SignMessage(s *MessageSigner) requester {
+	if s == nil {
+		return r.SetSigner(nil)
+	}
+	return r.SetSigner(s)
+}
+
+// SignMessages signs every request with s.
+func (c *Client) SignMessages(s *MessageSigner) *Client {
+	if s == nil {
+		return c.SetSigner(nil)
+	}
+	return c.SetSigner(s)
+}
+
+// Sign adds signature of req.
+func (s *MessageSigner) Sign(req *http.Request) error {
+	components := s.Components
+	if len(components) == 0 {
+		components = []string{"@method", "@authority", "@path", "@query"}
+		if req.Header.Get("Content-Digest") != "" {
+			components = append(components, "content-digest")
+		}
+	}
+
+	items := make([]SFItem, len(components))
+	for i, c := range components {
+		it, err := parseComponent(c)
+		if err != nil {
+			return err
+		}
+		items[i] = it
+	}
+
+	p := sigParams{
+		components: items,
+		created:    time.Now().Unix(),
+		keyID:      s.KeyID,
+		alg:        s.Alg,
+		tag:        s.Tag,
+	}
+	if s.Expires > 0 {
+		p.expires = p.created + int64(s.Expires/time.Second)
+	}
+	raw, err := FormatSFItem(p.item())
+	if err != nil {
+		return fmt.Errorf("signature params: %w", err)
+	}
+
+	base, err := signatureBase(items, raw, req, nil)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signMessage(s.Alg, s.Key, base)
+	if err != nil {
+		return err
+	}
+
+	label := s.Label
+	if label == "" {
+		label = "sig1"
+	}
+	input, err := FormatSFDict(SFDict{{label, p.item()}})
+	if err != nil {
+		return fmt.Errorf("signature input: %w", err)
+	}
+	signature, _ := FormatSFDict(SFDict{{label, SFItem{Value: sig}}})
+	appendHeader(req.Header, "Signature-Input", input)
+	appendHeader(req.Header, "Signature", signature)
+	return nil
+}
+
+func appendHeader(h http.Header, k, v string) {
+	if old := h.Get(k); old != "" {
+		v = old + ", " + v
+	}
+	h.Set(k, v)
+}
+
+// MessageVerifier verifies HTTP Message Signatures (RFC 9421)
+// of requests and responses.
+type MessageVerifier struct {
+	// Key returns algorithm and key of keyid: []byte for HMAC,
+	// ed25519.PublicKey, *ecdsa.PublicKey or *rsa.PublicKey.
+	Key func(keyID string) (alg string, key any, err error)
+	// Label of verified signature. If empty, the first signature is verified.
+	Label string
+	// Required components must be covered by the signature.
+	Required []string
+	// MaxAge rejects signatures created earlier, if not zero.
+	MaxAge time.Duration
+}
+
+// VerifyRequest verifies signature of req, e.g. in a server handler.
+func (v *MessageVerifier) VerifyRequest(req *http.Request) error {
+	return v.verify(req.Header, req, nil)
+}
+
+// VerifyResponse verifies signature of resp. Derived components
+// of the request are not supported, only @status and headers.
+func (v *MessageVerifier) VerifyResponse(resp *Response) error {
+	return v.verify(resp.Response.Header, nil, resp.Response)
+}
+
+func (v *MessageVerifier) verify(h http.Header, req *http.Request, resp *http.Response) error {
+	inputs, err := ParseSFDict(strings.Join(h.Values("Signature-Input"), ", "))
+	if err != nil {
+		return fmt.Errorf("signature input: %w", err)
+	}
+	sigs, err := ParseSFDict(strings.Join(h.Values("Signature"), ", "))
+	if err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+
+	label := v.Label
+	if label == "" && len(inputs) > 0 {
+		label = inputs[0].Key
+	}
+	input, ok := inputs.Get(label)
+	if !ok {
+		return fmt.Errorf("signature %q: %w: missing", label, ErrInvalidSignature)
+	}
+	sigItem, ok := sigs.Get(label)
+	if !ok {
+		return fmt.Errorf("signature %q: %w: missing", label, ErrInvalidSignature)
+	}
+	sig, ok := sigItem.Value.([]byte)
+	if !ok {
+		return fmt.Errorf("signature %q: %w: not a byte sequence", label, ErrInvalidSignature)
+	}
+
+	p, err := parseSigParams(input)
+	if err != nil {
+		return fmt.Errorf("signature input %q: %w", label, err)
+	}
+
+	covered := make([]string, len(p.components))
+	for i, c := range p.components {
+		if covered[i], err = FormatSFItem(c); err != nil {
+			return fmt.Errorf("signature input %q: %w", label, err)
+		}
+	}
+	for _, c := range v.Required {
+		it, err := parseComponent(c)
+		if err != nil {
+			return err
+		}
+		id, err := FormatSFItem(it)
+		if err != nil {
+			return fmt.Errorf("signature component %s: %w", c, err)
+		}
+		if !containsString(covered, id) {
+			return fmt.Errorf("signature %q: %w: %s not covered", label, ErrInvalidSignature, c)
+		}
+	}
+
+	now := time.Now()
+	if p.expires > 0 && now.Unix() > p.expires {
+		return fmt.Errorf("signature %q: %w: expired", label, ErrInvalidSignature)
+	}
+	if v.MaxAge > 0 && now.Sub(time.Unix(p.created, 0)) > v.MaxAge {
+		return fmt.Errorf("signature %q: %w: too old", label, ErrInvalidSignature)
+	}
+
+	alg, key, err := v.Key(p.keyID)
+	if err != nil {
+		return err
+	}
+	if p.alg != "" && p.alg != alg {
+		return fmt.Errorf("signature %q: %w: algorithm %s", label, ErrInvalidSignature, p.alg)
+	}
+
+	raw, err := FormatSFItem(input)
+	if err != nil {
+		return fmt.Errorf("signature input %q: %w", label, err)
+	}
+	base, err := signatureBase(p.components, raw, req, resp)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyMessage(alg, key, base, sig); err != nil {
+		return fmt.Errorf("signature %q: %w", label, err)
+	}
+	return nil
+}
+
+// parseComponent parses component identifier, a name like @method or
+// content-type, or serialized identifier with parameters like
+// "content-type";sf or "@query-param";name="id".
+func parseComponent(s string) (SFItem, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return SFItem{Value: s}, nil
+	}
+	it, err := ParseSFItem(s)
+	if err != nil {
+		return SFItem{}, fmt.Errorf("signature component %s: %w", s, err)
+	}
+	if _, ok := it.Value.(string); !ok {
+		return SFItem{}, fmt.Errorf("signature component %s: not a string", s)
+	}
+	return it, nil
+}
+
+// signatureBase creates signature base of components of req or resp.
+func signatureBase(components []SFItem, params string, req *http.Request, resp *http.Response) ([]byte, error) {
+	var b bytes.Buffer
+	for _, c := range components {
+		id, err := FormatSFItem(c)
+		if err != nil {
+			return nil, fmt.Errorf("signature component: %w", err)
+		}
+		values, err := componentValues(c, req, resp)
+		if err != nil {
+			return nil, fmt.Errorf("signature component %s: %w", id, err)
+		}
+		for _, v := range values {
+			b.WriteString(id + ": " + v + "\n")
+		}
+	}
+	b.WriteString(`"@signature-params": `)
+	b.WriteString(params)
+	return b.Bytes(), nil
+}
+
+// componentValues returns values of component c, one per line
+// of signature base. Only @query-param can have several values.
+func componentValues(c SFItem, req *http.Request, resp *http.Response) ([]string, error) {
+	name, _ := c.Value.(string)
+	for _, p := range c.Params {
+		switch p.Key {
+		case "sf", "bs", "req":
+			if p.Value != true {
+				return nil, fmt.Errorf("invalid %s parameter", p.Key)
+			}
+		case "key", "name":
+			if _, ok := p.Value.(string); !ok {
+				return nil, fmt.Errorf("invalid %s parameter", p.Key)
+			}
+		default:
+			return nil, fmt.Errorf("parameter %s not supported", p.Key)
+		}
+	}
+
+	if _, ok := c.Params.Get("req"); ok {
+		if resp == nil || resp.Request == nil {
+			return nil, errors.New("request of the response is not available")
+		}
+		req, resp = resp.Request, nil
+	}
+
+	if strings.HasPrefix(name, "@") {
+		if name == "@query-param" {
+			return queryParamValues(c.Params, req)
+		}
+		for _, p := range c.Params {
+			if p.Key != "req" {
+				return nil, fmt.Errorf("parameter %s not supported", p.Key)
+			}
+		}
+		v, err := derivedValue(name, req, resp)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+
+	var h http.Header
+	if resp != nil {
+		h = resp.Header
+	} else {
+		h = req.Header
+	}
+	values := append([]string{}, h.Values(name)...)
+	if len(values) == 0 {
+		return nil, errors.New("missing header")
+	}
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+
+	_, sf := c.Params.Get("sf")
+	_, bs := c.Params.Get("bs")
+	key, hasKey := c.Params.Get("key")
+	switch {
+	case bs && (sf || hasKey):
+		return nil, errors.New("bs parameter with sf or key")
+	case bs:
+		for i, v := range values {
+			values[i] = ":" + base64.StdEncoding.EncodeToString([]byte(v)) + ":"
+		}
+		return []string{strings.Join(values, ", ")}, nil
+	case hasKey:
+		d, err := ParseSFDict(strings.Join(values, ", "))
+		if err != nil {
+			return nil, err
+		}
+		it, ok := d.Get(key.(string))
+		if !ok {
+			return nil, fmt.Errorf("missing dictionary member %s", key)
+		}
+		v, err := FormatSFItem(it)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	case sf:
+		v, err := reformatSF(strings.Join(values, ", "))
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+	return []string{strings.Join(values, ", ")}, nil
+}
+
+// reformatSF serializes structured field s again, as dictionary
+// or as list. Items are lists of one member.
+func reformatSF(s string) (string, error) {
+	if d, err := ParseSFDict(s); err == nil {
+		return FormatSFDict(d)
+	}
+	l, err := ParseSFList(s)
+	if err != nil {
+		return "", err
+	}
+	return FormatSFList(l)
+}
+
+// queryParamValues returns values of query parameter set
+// by name parameter, percent-encoded.
+func queryParamValues(params SFParams, req *http.Request) ([]string, error) {
+	name, ok := params.Get("name")
+	if !ok {
+		return nil, errors.New("missing name parameter")
+	}
+	q, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	values := q[name.(string)]
+	if len(values) == 0 {
+		return nil, errors.New("missing query parameter")
+	}
+	encoded := make([]string, len(values))
+	for i, v := range values {
+		encoded[i] = strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+	}
+	return encoded, nil
+}
+
+func derivedValue(name string, req *http.Request, resp *http.Response) (string, error) {
+	if resp != nil {
+		if name == "@status" {
+			return strconv.Itoa(resp.StatusCode), nil
+		}
+		return "", errors.New("not supported for responses")
+	}
+
+	switch name {
+	case "@method":
+		return req.Method, nil
+	case "@target-uri":
+		return requestScheme(req) + "://" + requestAuthority(req) + req.URL.RequestURI(), nil
+	case "@authority":
+		return requestAuthority(req), nil
+	case "@scheme":
+		return requestScheme(req), nil
+	case "@request-target":
+		return req.URL.RequestURI(), nil
+	case "@path":
+		if p := req.URL.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + req.URL.RawQuery, nil
+	}
+	return "", errors.New("not supported")
+}
+
+func requestScheme(req *http.Request) string {
+	if req.URL.Scheme != "" {
+		return strings.ToLower(req.URL.Scheme)
+	}
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// requestAuthority returns lowercase host without default port.
+func requestAuthority(req *http.Request) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	host = strings.ToLower(host)
+
+	switch requestScheme(req) {
+	case "http":
+		host = strings.TrimSuffix(host, ":80")
+	case "https":
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+type sigParams struct {
+	components []SFItem
+	created    int64
+	expires    int64
+	keyID      string
+	alg        string
+	tag        string
+}
+
+// item returns params as inner list of components with parameters.
+func (p sigParams) item() SFItem {
+	params := SFParams{{"created", p.created}}
+	if p.expires > 0 {
+		params = append(params, SFParam{"expires", p.expires})
+	}
+	if p.keyID != "" {
+		params = append(params, SFParam{"keyid", p.keyID})
+	}
+	if p.alg != "" {
+		params = append(params, SFParam{"alg", p.alg})
+	}
+	if p.tag != "" {
+		params = append(params, SFParam{"tag", p.tag})
+	}
+	return SFItem{Value: p.components, Params: params}
+}
+
+// parseSigParams parses member of Signature-Input, inner list of
+// components and parameters.
+func parseSigParams(it SFItem) (sigParams, error) {
+	p := sigParams{}
+	l, ok := it.Value.([]SFItem)
+	if !ok {
+		return p, errors.New("expected inner list")
+	}
+	for _, c := range l {
+		if _, ok := c.Value.(string); !ok {
+			return p, errors.New("component is not a string")
+		}
+	}
+	p.components = l
+
+	for _, param := range it.Params {
+		ok := true
+		switch param.Key {
+		case "created":
+			p.created, ok = param.Value.(int64)
+		case "expires":
+			p.expires, ok = param.Value.(int64)
+		case "keyid":
+			p.keyID, ok = param.Value.(string)
+		case "alg":
+			p.alg, ok = param.Value.(string)
+		case "tag":
+			p.tag, ok = param.Value.(string)
+		}
+		if !ok {
+			return p, fmt.Errorf("invalid %s parameter", param.Key)
+		}
+	}
+	return p, nil
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func signMessage(alg string, key any, base []byte) ([]byte, error) {
+	switch alg {
+	case SigHMACSHA256:
+		k, ok := key.([]byte)
+		if !ok {
+			break
+		}
+		m := hmac.New(sha256.New, k)
+		m.Write(base)
+		return m.Sum(nil), nil
+	case SigEd25519:
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			break
+		}
+		return ed25519.Sign(k, base), nil
+	case SigECDSAP256SHA256, SigECDSAP384SHA384:
+		k, ok := key.(*ecdsa.PrivateKey)
+		h, size := ecdsaHash(alg)
+		if !ok || k.Curve.Params().BitSize != 8*size {
+			break
+		}
+		d := h.New()
+		d.Write(base)
+		r, s, err := ecdsa.Sign(rand.Reader, k, d.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	case SigRSAPSSSHA512:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			break
+		}
+		d := sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, k, crypto.SHA512, d[:], &rsa.PSSOptions{SaltLength: 64})
+	case SigRSAv15SHA256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			break
+		}
+		d := sha256.Sum256(base)
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, d[:])
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	return nil, fmt.Errorf("invalid key %T for %s", key, alg)
+}
+
+func verifyMessage(alg string, key any, base, sig []byte) error {
+	if k, ok := key.(crypto.Signer); ok {
+		key = k.Public()
+	}
+
+	switch alg {
+	case SigHMACSHA256:
+		k, ok := key.([]byte)
+		if !ok {
+			break
+		}
+		m := hmac.New(sha256.New, k)
+		m.Write(base)
+		if !hmac.Equal(m.Sum(nil), sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case SigEd25519:
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			break
+		}
+		if !ed25519.Verify(k, base, sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case SigECDSAP256SHA256, SigECDSAP384SHA384:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			break
+		}
+		h, size := ecdsaHash(alg)
+		if len(sig) != 2*size {
+			return ErrInvalidSignature
+		}
+		d := h.New()
+		d.Write(base)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, d.Sum(nil), r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case SigRSAPSSSHA512:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			break
+		}
+		d := sha512.Sum512(base)
+		if rsa.VerifyPSS(k, crypto.SHA512, d[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case SigRSAv15SHA256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			break
+		}
+		d := sha256.Sum256(base)
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, d[:], sig) != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	return fmt.Errorf("invalid key %T for %s", key, alg)
+}
+
+// ecdsaHash returns hash and size of scalar of ECDSA algorithm.
+func ecdsaHash(alg string) (crypto.Hash, int) {
+	if alg == SigECDSAP384SHA384 {
+		return crypto.SHA384, (elliptic.P384().Params().BitSize + 7) / 8
+	}
+	return crypto.SHA256, (elliptic.P256().Params().BitSize + 7) / 8
+}
@@ -0,0 +1,177 @@
+package restreq
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var testHMACKey = []byte("secret")
+
+func testVerifier(required ...string) *MessageVerifier {
+	return &MessageVerifier{
+		Key: func(keyID string) (string, any, error) {
+			return SigHMACSHA256, testHMACKey, nil
+		},
+		Required: required,
+	}
+}
+
+func signedRequest(t *testing.T, components ...string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("POST", "https://example.com/items?id=a%20b&id=c&x=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Priority", "u=1,  i")
+	s := &MessageSigner{KeyID: "k", Alg: SigHMACSHA256, Key: testHMACKey, Components: components}
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestSignatureComponents(t *testing.T) {
+	tests := []struct {
+		component string
+		line      string
+	}{
+		{"@method", `"@method": POST`},
+		{"@authority", `"@authority": example.com`},
+		{"@query", `"@query": ?id=a%20b&id=c&x=1`},
+		{"content-type", `"content-type": application/json`},
+		{`"content-type";bs`, `"content-type";bs: :YXBwbGljYXRpb24vanNvbg==:`},
+		{`"priority";sf`, `"priority";sf: u=1, i`},
+		{`"priority";key="u"`, `"priority";key="u": 1`},
+		{`"@query-param";name="id"`, `"@query-param";name="id": a%20b` + "\n" + `"@query-param";name="id": c`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.component, func(t *testing.T) {
+			it, err := parseComponent(tt.component)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := signedRequest(t, tt.component)
+			base, err := signatureBase([]SFItem{it}, "()", req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(string(base), "\n\"@signature-params\": ()"); got != tt.line {
+				t.Errorf("base = %q, want %q", got, tt.line)
+			}
+			if err := testVerifier(tt.component).VerifyRequest(req); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSignatureInvalidComponents(t *testing.T) {
+	for _, c := range []string{
+		`"content-type";bs;sf`,
+		`"content-type";tr`,
+		`"@method";sf`,
+		`"@query-param"`,
+		`"priority";key="missing"`,
+		`"x-missing"`,
+		`"@status"`,
+	} {
+		req, _ := http.NewRequest("GET", "https://example.com/", nil)
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Priority", "u=1")
+		s := &MessageSigner{Alg: SigHMACSHA256, Key: testHMACKey, Components: []string{c}}
+		if err := s.Sign(req); err == nil {
+			t.Errorf("%s: signed", c)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*http.Request)
+		want   error
+	}{
+		{"valid", func(*http.Request) {}, nil},
+		{"method", func(r *http.Request) { r.Method = "PUT" }, ErrInvalidSignature},
+		{"query param", func(r *http.Request) { r.URL.RawQuery = "id=c&id=a%20b&x=1" }, ErrInvalidSignature},
+		{"other param", func(r *http.Request) { r.URL.RawQuery += "&y=2" }, nil},
+		{"missing", func(r *http.Request) { r.Header.Del("Signature") }, ErrInvalidSignature},
+		{"not bytes", func(r *http.Request) { r.Header.Set("Signature", `sig1="abc"`) }, ErrInvalidSignature},
+		{"required", func(r *http.Request) {}, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := signedRequest(t, "@method", `"@query-param";name="id"`)
+			tt.modify(req)
+			v := testVerifier(`"@query-param";name="id"`)
+			if tt.name == "required" {
+				v = testVerifier("@path")
+			}
+			err := v.VerifyRequest(req)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyResponseRequestComponent(t *testing.T) {
+	req := signedRequest(t, "@method")
+	resp := &http.Response{StatusCode: 200, Header: http.Header{}, Request: req}
+	resp.Header.Set("Content-Type", "text/plain")
+
+	s := &MessageSigner{Alg: SigHMACSHA256, Key: testHMACKey,
+		Components: []string{"@status", "content-type", `"@method";req`}}
+	items := make([]SFItem, len(s.Components))
+	for i, c := range s.Components {
+		items[i], _ = parseComponent(c)
+	}
+	p := sigParams{components: items, created: 1}
+	raw, _ := FormatSFItem(p.item())
+	base, err := signatureBase(items, raw, nil, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(base), `"@method";req: POST`) {
+		t.Errorf("base = %q", base)
+	}
+	sig, _ := signMessage(SigHMACSHA256, testHMACKey, base)
+	input, _ := FormatSFDict(SFDict{{"sig1", p.item()}})
+	signature, _ := FormatSFDict(SFDict{{"sig1", SFItem{Value: sig}}})
+	resp.Header.Set("Signature-Input", input)
+	resp.Header.Set("Signature", signature)
+
+	if err := testVerifier().VerifyResponse(&Response{Response: resp}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzVerifySignature(f *testing.F) {
+	req, _ := http.NewRequest("GET", "https://example.com/items", nil)
+	req.Header.Set("Priority", "u=1, i")
+	s := &MessageSigner{Alg: SigHMACSHA256, Key: testHMACKey, Components: []string{"@method", `"priority";sf`}}
+	if err := s.Sign(req); err != nil {
+		f.Fatal(err)
+	}
+	signed, _ := ParseSFDict(req.Header.Get("Signature-Input"))
+	want, _ := FormatSFItem(signed[0].Item)
+
+	f.Add(req.Header.Get("Signature-Input"), req.Header.Get("Signature"))
+	f.Add(`sig1=("@query-param";name="id" "content-type";bs);created=1`, `sig1=:AAAA:`)
+	f.Add(`sig1=("priority";key="u");keyid="k";alg="hmac-sha256"`, `sig1=?1`)
+	f.Fuzz(func(t *testing.T, input, signature string) {
+		r := req.Clone(req.Context())
+		r.Header.Set("Signature-Input", input)
+		r.Header.Set("Signature", signature)
+		if err := testVerifier().VerifyRequest(r); err != nil {
+			return
+		}
+		d, _ := ParseSFDict(input)
+		if got, _ := FormatSFItem(d[0].Item); got != want {
+			t.Fatalf("verified forged signature input %q", input)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package restreq
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Signer signs request after its body and all headers are set, just
+// before it is sent. Requests are signed again for every attempt.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc adapts function to Signer.
+type SignerFunc func(req *http.Request) error
+
+// Sign calls f.
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// SetSigner sets signer of the request. It replaces signer
+// of the client.
+func (r *Request) SetSigner(s Signer) requester {
+	r.signer = s
+	return r
+}
+
+// SetSigner sets signer of every request.
+func (c *Client) SetSigner(s Signer) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signer = s
+	return c
+}
+
+var errStreamedBody = errors.New("streamed body can't be signed")
+
+// requestBody returns body of req read with GetBody,
+// errStreamedBody if it can't be read again.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errStreamedBody
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
@@ -0,0 +1,228 @@
+package restreq
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are AWS access keys. SessionToken is set
+// for temporary credentials.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSCredentialsProvider returns credentials every time a request
+// is signed, so temporary credentials can be refreshed.
+// AWSCredentials returns itself.
+type AWSCredentialsProvider interface {
+	AWSCredentials(ctx context.Context) (AWSCredentials, error)
+}
+
+// AWSCredentials returns c.
+func (c AWSCredentials) AWSCredentials(context.Context) (AWSCredentials, error) {
+	return c, nil
+}
+
+// SigV4Signer signs requests with AWS Signature Version 4.
+type SigV4Signer struct {
+	Region      string
+	Service     string
+	Credentials AWSCredentialsProvider
+	// UnsignedPayload sends UNSIGNED-PAYLOAD instead of hash of
+	// the body, supported by S3. It is used for streamed bodies.
+	UnsignedPayload bool
+
+	now func() time.Time
+}
+
+// SetAWSSigV4 signs the request with AWS Signature Version 4,
+// e.g. SetAWSSigV4("eu-west-1", "s3", restreq.AWSCredentials{...}).
+func (r *Request) SetAWSSigV4(region, service string, credentials AWSCredentialsProvider) requester {
+	return r.SetSigner(&SigV4Signer{Region: region, Service: service, Credentials: credentials})
+}
+
+// SetAWSSigV4 signs every request with AWS Signature Version 4.
+func (c *Client) SetAWSSigV4(region, service string, credentials AWSCredentialsProvider) *Client {
+	return c.SetSigner(&SigV4Signer{Region: region, Service: service, Credentials: credentials})
+}
+
+// headers not signed, as they can be changed by proxies.
+var sigV4Ignored = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+}
+
+// Sign sets Authorization, X-Amz-Date and, for temporary credentials,
+// X-Amz-Security-Token headers. S3 requests get X-Amz-Content-Sha256.
+func (s *SigV4Signer) Sign(req *http.Request) error {
+	if s.Credentials == nil {
+		return errors.New("sigv4: no credentials")
+	}
+	creds, err := s.Credentials.AWSCredentials(req.Context())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	scope := t.Format("20060102") + "/" + s.Region + "/" + s.Service + "/aws4_request"
+
+	payloadHash, err := s.payloadHash(req)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	canonical, signed := s.canonicalRequest(req, payloadHash)
+	h := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(h[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, p := range []string{t.Format("20060102"), s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, p)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+sig)
+	return nil
+}
+
+// canonicalRequest returns canonical request of req and names
+// of signed headers.
+func (s *SigV4Signer) canonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string][]string{"host": {host}}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if !sigV4Ignored[k] {
+			headers[k] = append(headers[k], v...)
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n")
+	canonical.WriteString(s.canonicalPath(req.URL) + "\n")
+	canonical.WriteString(canonicalQuery(req.URL) + "\n")
+	for _, k := range names {
+		v := make([]string, len(headers[k]))
+		for i, hv := range headers[k] {
+			v[i] = strings.Join(strings.Fields(hv), " ")
+		}
+		canonical.WriteString(k + ":" + strings.Join(v, ",") + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical.WriteString("\n" + signed + "\n" + payloadHash)
+	return canonical.String(), signed
+}
+
+// payloadHash returns hex SHA-256 of the body, read with GetBody.
+func (s *SigV4Signer) payloadHash(req *http.Request) (string, error) {
+	if s.UnsignedPayload {
+		return "UNSIGNED-PAYLOAD", nil
+	}
+
+	body, err := requestBody(req)
+	if err == errStreamedBody && s.Service == "s3" {
+		return "UNSIGNED-PAYLOAD", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sigv4: %w", err)
+	}
+	h := sha256.Sum256(body)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// canonicalPath encodes segments of the path as sent once, which
+// encodes them twice, except for S3, which gets the decoded path
+// encoded once.
+func (s *SigV4Signer) canonicalPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if s.Service == "s3" {
+		p = u.Path
+	}
+	if p == "" {
+		return "/"
+	}
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns query sorted by keys and values.
+func canonicalQuery(u *url.URL) string {
+	q, _ := url.ParseQuery(u.RawQuery)
+	pairs := make([][2]string, 0, len(q))
+	for k, values := range q {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{awsEscape(k), awsEscape(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	s := make([]string, len(pairs))
+	for i, p := range pairs {
+		s[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(s, "&")
+}
+
+// awsEscape percent-encodes all but unreserved characters.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAlpha(c) || isDigit(c) || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
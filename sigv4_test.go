@@ -0,0 +1,140 @@
+package restreq
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSigV4 is signer of the AWS Signature Version 4 test suite.
+func testSigV4(service string) *SigV4Signer {
+	return &SigV4Signer{
+		Region:  "us-east-1",
+		Service: service,
+		Credentials: AWSCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		now: func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+}
+
+func TestSigV4Vectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		method  string
+		url     string
+		header  map[string]string
+		sig     string
+	}{
+		{
+			name:    "get-vanilla",
+			service: "service",
+			method:  "GET",
+			url:     "https://example.amazonaws.com/",
+			sig:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:    "get-vanilla-query-order-key-case",
+			service: "service",
+			method:  "GET",
+			url:     "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			sig:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:    "post-vanilla",
+			service: "service",
+			method:  "POST",
+			url:     "https://example.amazonaws.com/",
+			sig:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:    "iam-list-users",
+			service: "iam",
+			method:  "GET",
+			url:     "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			header:  map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"},
+			sig:     "5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if err := testSigV4(tt.service).Sign(req); err != nil {
+				t.Fatal(err)
+			}
+			auth := req.Header.Get("Authorization")
+			if !strings.HasSuffix(auth, "Signature="+tt.sig) {
+				t.Errorf("Authorization = %s, want signature %s", auth, tt.sig)
+			}
+			if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %s", req.Header.Get("X-Amz-Date"))
+			}
+		})
+	}
+}
+
+func TestSigV4CanonicalPath(t *testing.T) {
+	tests := []struct {
+		service string
+		path    string
+		want    string
+	}{
+		{"bedrock", "/model/anthropic.claude-v2:1/invoke", "/model/anthropic.claude-v2%3A1/invoke"},
+		{"service", "/a@b=c+d$e,f;g&h/", "/a%40b%3Dc%2Bd%24e%2Cf%3Bg%26h/"},
+		{"service", "/example%20space/", "/example%2520space/"},
+		{"service", "/%E1%88%B4", "/%25E1%2588%25B4"},
+		{"service", "", "/"},
+		{"s3", "/bucket/a%20b:c+d", "/bucket/a%20b%3Ac%2Bd"},
+		{"s3", "/bucket/%E1%88%B4", "/bucket/%E1%88%B4"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "https://example.amazonaws.com"+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := testSigV4(tt.service).canonicalPath(req.URL); got != tt.want {
+			t.Errorf("%s %s: canonicalPath = %s, want %s", tt.service, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSigV4CanonicalRequest(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers", nil)
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+	req.Header.Set("X-Custom", "  a   b ")
+	req.Header.Set("User-Agent", "ignored")
+
+	canonical, signed := testSigV4("iam").canonicalRequest(req, emptySHA256)
+	want := "GET\n/\nAction=ListUsers&Version=2010-05-08\n" +
+		"host:iam.amazonaws.com\nx-amz-date:20150830T123600Z\nx-custom:a b\n\n" +
+		"host;x-amz-date;x-custom\n" + emptySHA256
+	if canonical != want || signed != "host;x-amz-date;x-custom" {
+		t.Errorf("canonical request = %q, signed %q", canonical, signed)
+	}
+}
+
+func TestSigV4S3(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "https://s3.amazonaws.com/bucket/key", strings.NewReader("data"))
+	creds := AWSCredentials{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "token"}
+	s := &SigV4Signer{Region: "us-east-1", Service: "s3", Credentials: creds}
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7" {
+		t.Errorf("X-Amz-Content-Sha256 = %s", got)
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "token" || !strings.Contains(req.Header.Get("Authorization"), "x-amz-security-token") {
+		t.Errorf("session token not signed: %v", req.Header)
+	}
+}
+
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
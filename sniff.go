@@ -0,0 +1,77 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// SniffContentType makes Response.Decode detect type of the body, when
+// Content-Type of the response is missing, generic like text/plain,
+// or has no registered codec. JSON and XML are detected, other types
+// by http.DetectContentType.
+func (r *Request) SniffContentType() requester {
+	r.sniff = true
+	return r
+}
+
+// SniffContentType makes every request detect type of
+// response body, see Request.SniffContentType.
+func (c *Client) SniffContentType() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sniff = true
+	return c
+}
+
+// ContentType returns Content-Type of the response used by Decode,
+// which is detected from the body if sniffing is enabled and the
+// header is missing or wrong.
+func (r *Response) ContentType() string {
+	ct := r.Header("Content-Type")
+	if !r.sniff || !untrusted(ct) {
+		return ct
+	}
+	if s := sniff(r.Body); s != "" {
+		return s
+	}
+	return ct
+}
+
+// untrusted reports whether Content-Type does not say
+// how to decode the body.
+func untrusted(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return true
+	}
+	switch mt {
+	case "text/plain", "application/octet-stream", "binary/octet-stream":
+		return true
+	}
+	_, err = codecFor(mt)
+	return err != nil
+}
+
+// sniff returns media type of body, or empty string for empty body.
+func sniff(body []byte) string {
+	b := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(b) == 0 {
+		return ""
+	}
+
+	switch b[0] {
+	case '{', '[':
+		if json.Valid(b) {
+			return "application/json"
+		}
+	case '<':
+		if bytes.HasPrefix(b, []byte("<?xml")) {
+			return "application/xml"
+		}
+	}
+
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(b))
+	return mt
+}
@@ -0,0 +1,133 @@
+package restreq
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// socketOptions are options of connections dialed by a Client.
+type socketOptions struct {
+	control   func(network, address string, c syscall.RawConn) error
+	delay     bool
+	keepAlive time.Duration
+	dscp      int
+}
+
+// SetDialControl sets function called with raw connection after it is
+// created and before it is connected, like net.Dialer.Control. It can set
+// socket options not covered by other setters. Socket options must be
+// set before the client sends requests.
+func (c *Client) SetDialControl(f func(network, address string, rc syscall.RawConn) error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.socket.control = f
+	c.applySocketOptions()
+	return c
+}
+
+// SetTCPNoDelay sets TCP_NODELAY, true by default. Disabling it
+// enables Nagle's algorithm.
+func (c *Client) SetTCPNoDelay(noDelay bool) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.socket.delay = !noDelay
+	c.applySocketOptions()
+	return c
+}
+
+// SetKeepAlive sets interval of TCP keep-alive probes, 30s by default.
+// Negative interval disables keep-alive probes.
+func (c *Client) SetKeepAlive(d time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.socket.keepAlive = d
+	c.applySocketOptions()
+	return c
+}
+
+// SetDSCP marks IP packets with DSCP value 0-63, e.g. 46 for
+// expedited forwarding. Only supported on unix systems,
+// otherwise dialing fails.
+func (c *Client) SetDSCP(dscp int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.socket.dscp = dscp
+	c.applySocketOptions()
+	return c
+}
+
+// applySocketOptions sets dialer of the client transport
+// and transports of quirks.
+func (c *Client) applySocketOptions() {
+	c.eachTransport(func(t *http.Transport) {
+		t.DialContext = c.socket.dialContext()
+	})
+}
+
+// eachTransport calls f with *http.Transport of the client and of
+// its quirks. Profiles are reset to be built with the new settings.
+func (c *Client) eachTransport(f func(*http.Transport)) {
+	c.resetProfiles()
+	clients := []*http.Client{c.httpClient}
+	for _, q := range c.quirks {
+		if q.client != nil {
+			clients = append(clients, q.client)
+		}
+	}
+
+	for _, hc := range clients {
+		if t, ok := hc.Transport.(*http.Transport); ok {
+			f(t)
+		}
+	}
+}
+
+func (o socketOptions) dialContext() func(ctx context.Context, network, address string) (net.Conn, error) {
+	d := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   o.controlFunc(),
+	}
+	if o.keepAlive != 0 {
+		d.KeepAlive = o.keepAlive
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		if tc, ok := conn.(*net.TCPConn); ok && o.delay {
+			if err := tc.SetNoDelay(false); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+}
+
+func (o socketOptions) controlFunc() func(network, address string, rc syscall.RawConn) error {
+	if o.dscp == 0 && o.control == nil {
+		return nil
+	}
+
+	return func(network, address string, rc syscall.RawConn) error {
+		if o.dscp != 0 {
+			if o.dscp < 0 || o.dscp > 63 {
+				return fmt.Errorf("invalid DSCP: %d", o.dscp)
+			}
+			if err := setDSCP(network, rc, o.dscp); err != nil {
+				return err
+			}
+		}
+		if o.control != nil {
+			return o.control(network, address, rc)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package restreq
+
+import (
+	"errors"
+	"syscall"
+)
+
+func setDSCP(network string, rc syscall.RawConn, dscp int) error {
+	return errors.New("DSCP is not supported on this platform")
+}
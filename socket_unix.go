@@ -0,0 +1,21 @@
+//go:build unix
+
+package restreq
+
+import "syscall"
+
+// setDSCP sets DSCP in the upper six bits of the traffic class.
+func setDSCP(network string, rc syscall.RawConn, dscp int) error {
+	var err error
+	cerr := rc.Control(func(fd uintptr) {
+		if network == "tcp6" || network == "udp6" {
+			err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, dscp<<2)
+		} else {
+			err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, dscp<<2)
+		}
+	})
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
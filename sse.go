@@ -0,0 +1,150 @@
+package restreq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is event of text/event-stream.
+type Event struct {
+	// ID is last event ID of the stream.
+	ID string
+	// Event is type of the event, message if not set.
+	Event string
+	Data  string
+	// Retry is reconnection time sent with the event, if any.
+	Retry time.Duration
+}
+
+// defaultEventRetry is reconnection time until the server sets one.
+const defaultEventRetry = 3 * time.Second
+
+// Events connects to Server-Sent Events stream with GET and calls f
+// for every event. When the stream ends or the connection fails, it
+// reconnects after retry time sent by the server, 3 seconds by default,
+// with Last-Event-ID header. It returns when context of the request is
+// done, f returns an error, the request fails with a response, e.g.
+// by error detector, or the server responds with status other than
+// 200, which is nil for 204 No Content. Timeout of the request applies
+// to every connection.
+func (r *Request) Events(f func(Event) error) error {
+	retry := defaultEventRetry
+	var lastID string
+
+	for {
+		c := r.clone()
+		c.bodyReader = true
+		c.headers["Accept"] = "text/event-stream"
+		c.headers["Cache-Control"] = "no-cache"
+		if lastID != "" {
+			c.headers["Last-Event-ID"] = lastID
+		}
+
+		resp, err := c.do(http.MethodGet)
+		if err != nil {
+			if resp != nil && resp.streamed {
+				resp.Response.Body.Close()
+			}
+			var netErr *url.Error
+			if resp != nil || !errors.As(err, &netErr) {
+				return err
+			}
+		} else if done, err := readEvents(resp, &lastID, &retry, f); done {
+			return err
+		}
+
+		if c.ctx != nil && c.ctx.Err() != nil {
+			return c.ctx.Err()
+		}
+
+		if err := r.sleep(retry); err != nil {
+			return err
+		}
+	}
+}
+
+// readEvents reads events of resp until the stream ends. done is
+// true if Events should return err without reconnecting.
+func readEvents(resp *Response, lastID *string, retry *time.Duration, f func(Event) error) (done bool, err error) {
+	defer resp.Response.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return true, fmt.Errorf("event stream: unexpected status %s", resp.Status)
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header("Content-Type")); mt != "text/event-stream" {
+		return true, fmt.Errorf("event stream: unexpected content type %q", resp.Header("Content-Type"))
+	}
+
+	var (
+		br    = bufio.NewReader(resp.Response.Body)
+		event string
+		data  strings.Builder
+		sent  time.Duration
+	)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if data.Len() > 0 {
+				e := Event{
+					ID:    *lastID,
+					Event: event,
+					Data:  strings.TrimSuffix(data.String(), "\n"),
+					Retry: sent,
+				}
+				if e.Event == "" {
+					e.Event = "message"
+				}
+				if err := f(e); err != nil {
+					return true, err
+				}
+			}
+			event, sent = "", 0
+			data.Reset()
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				*lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+				*retry = time.Duration(ms) * time.Millisecond
+				sent = *retry
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package restreq
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func eventResponse(status int, contentType, body string) *Response {
+	return &Response{Response: &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {contentType}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}}
+}
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   []Event
+		lastID string
+		retry  time.Duration
+	}{
+		{
+			name: "message",
+			body: "data: hello\n\n",
+			want: []Event{{Event: "message", Data: "hello"}},
+		},
+		{
+			name:   "fields",
+			body:   ": comment\r\nevent: update\r\ndata: a\r\ndata:b\r\nid: 7\r\nretry: 1500\r\n\r\n",
+			want:   []Event{{ID: "7", Event: "update", Data: "a\nb", Retry: 1500 * time.Millisecond}},
+			lastID: "7",
+			retry:  1500 * time.Millisecond,
+		},
+		{
+			name:   "id kept",
+			body:   "id: 1\ndata: x\n\ndata: y\n\nid\ndata: z\n\n",
+			want:   []Event{{ID: "1", Event: "message", Data: "x"}, {ID: "1", Event: "message", Data: "y"}, {Event: "message", Data: "z"}},
+			lastID: "",
+		},
+		{
+			name:  "ignored",
+			body:  "event: empty\n\nid: a\x00b\nretry: soon\nunknown: 1\ndata\n\n",
+			want:  []Event{{Event: "message", Data: ""}},
+			retry: defaultEventRetry,
+		},
+		{
+			name: "unterminated",
+			body: "data: lost",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Event
+			lastID, retry := "", defaultEventRetry
+			done, err := readEvents(eventResponse(200, "text/event-stream; charset=utf-8", tt.body), &lastID, &retry, func(e Event) error {
+				got = append(got, e)
+				return nil
+			})
+			if tt.retry == 0 {
+				tt.retry = defaultEventRetry
+			}
+			if done || err != nil || !reflect.DeepEqual(got, tt.want) || lastID != tt.lastID || retry != tt.retry {
+				t.Errorf("readEvents = %v, %v, %+v, id %q, retry %v", done, err, got, lastID, retry)
+			}
+		})
+	}
+}
+
+func TestReadEventsDone(t *testing.T) {
+	stop := errors.New("stop")
+	tests := []struct {
+		name string
+		resp *Response
+		f    func(Event) error
+		err  bool
+	}{
+		{"no content", eventResponse(204, "", ""), nil, false},
+		{"status", eventResponse(500, "text/event-stream", ""), nil, true},
+		{"content type", eventResponse(200, "text/plain", "data: x\n\n"), nil, true},
+		{"callback", eventResponse(200, "text/event-stream", "data: x\n\n"), func(Event) error { return stop }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lastID, retry := "", defaultEventRetry
+			done, err := readEvents(tt.resp, &lastID, &retry, tt.f)
+			if !done || (err != nil) != tt.err {
+				t.Errorf("readEvents = %v, %v", done, err)
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	detected := errors.New("detected")
+	stream := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "retry: 1\nid: 1\ndata: x\n\n")
+	}
+	fail := func(w http.ResponseWriter) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+	tests := []struct {
+		name     string
+		handlers []func(w http.ResponseWriter)
+		detect   ErrorDetector
+		err      error
+	}{
+		{"stream ends", []func(http.ResponseWriter){stream, stream}, nil, nil},
+		{"connection fails", []func(http.ResponseWriter){stream, fail}, nil, nil},
+		{"status", []func(http.ResponseWriter){func(w http.ResponseWriter) { w.WriteHeader(500) }}, nil, errors.New("status")},
+		{"detector", []func(http.ResponseWriter){stream}, func(*Response) error { return detected }, detected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conns++
+				if conns > 1 && r.Header.Get("Last-Event-ID") != "1" {
+					t.Errorf("Last-Event-ID = %q", r.Header.Get("Last-Event-ID"))
+				}
+				if conns > len(tt.handlers) {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				tt.handlers[conns-1](w)
+			}))
+			defer srv.Close()
+
+			r := New(srv.URL)
+			if tt.detect != nil {
+				r.AddErrorDetector(tt.detect)
+			}
+			err := r.Events(func(Event) error { return nil })
+			if tt.err == nil {
+				if err != nil || conns != len(tt.handlers)+1 {
+					t.Errorf("Events = %v after %d connections", err, conns)
+				}
+			} else if err == nil || tt.err == detected && !errors.Is(err, detected) || conns != 1 {
+				t.Errorf("Events = %v after %d connections", err, conns)
+			}
+		})
+	}
+}
+
+func FuzzReadEvents(f *testing.F) {
+	f.Add("event: a\ndata: 1\nid: 2\nretry: 10\n\n")
+	f.Add(": x\r\ndata:y\r\n\r\n")
+	f.Fuzz(func(t *testing.T, body string) {
+		lastID, retry := "", defaultEventRetry
+		_, err := readEvents(eventResponse(200, "text/event-stream", body), &lastID, &retry, func(e Event) error {
+			if e.Event == "" || strings.ContainsRune(e.ID, 0) {
+				t.Fatalf("invalid event %+v", e)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
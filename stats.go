@@ -0,0 +1,300 @@
+package restreq
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are upper bounds of histogram buckets.
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
+// Stats are latency statistics of requests sent by a Client, measured
+// until response headers are received, collected after EnableStats.
+// Endpoints and Counters are keyed by method and path passed to Client.New,
+// without query. Use templates like /users/{{id}} or SetPath for IDs,
+// so they are not counted as separate endpoints.
+type Stats struct {
+	Hosts     map[string]LatencyStats
+	Endpoints map[string]LatencyStats
+	Counters  map[string]Counters
+}
+
+// Counters count attempts of requests to an endpoint. Errors are
+// attempts failed with an error or 5xx response, Retries are attempts
+// after the first one, Violations are responses violating invariants.
+type Counters struct {
+	Requests   int64
+	Errors     int64
+	Retries    int64
+	Violations int64
+}
+
+// ErrorRate returns ratio of Errors to Requests.
+func (c Counters) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Requests)
+}
+
+// LatencyStats summarizes latency histogram.
+// Percentiles are interpolated within buckets.
+type LatencyStats struct {
+	Count   int64
+	Min     time.Duration
+	Max     time.Duration
+	Mean    time.Duration
+	P50     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+	Buckets []Bucket
+}
+
+// Bucket counts requests with latency up to UpperBound.
+// The last bucket has UpperBound 0 and counts all slower requests.
+type Bucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]int64, len(latencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool {
+		return d <= latencyBuckets[i]
+	})
+	h.counts[i]++
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+func (h *histogram) snapshot() LatencyStats {
+	s := LatencyStats{
+		Count:   h.count,
+		Min:     h.min,
+		Max:     h.max,
+		Buckets: make([]Bucket, len(h.counts)),
+	}
+
+	for i, c := range h.counts {
+		s.Buckets[i].Count = c
+		if i < len(latencyBuckets) {
+			s.Buckets[i].UpperBound = latencyBuckets[i]
+		}
+	}
+
+	if h.count > 0 {
+		s.Mean = h.sum / time.Duration(h.count)
+		s.P50 = h.quantile(0.50)
+		s.P95 = h.quantile(0.95)
+		s.P99 = h.quantile(0.99)
+	}
+	return s
+}
+
+func (h *histogram) quantile(q float64) time.Duration {
+	rank := q * float64(h.count)
+	var cum int64
+
+	for i, c := range h.counts {
+		if c == 0 || float64(cum+c) < rank {
+			cum += c
+			continue
+		}
+		if i == len(latencyBuckets) {
+			return h.max
+		}
+
+		lower := time.Duration(0)
+		if i > 0 {
+			lower = latencyBuckets[i-1]
+		}
+		upper := latencyBuckets[i]
+
+		d := lower + time.Duration(float64(upper-lower)*(rank-float64(cum))/float64(c))
+		if d > h.max {
+			d = h.max
+		}
+		if d < h.min {
+			d = h.min
+		}
+		return d
+	}
+	return h.max
+}
+
+// OtherStats is key of statistics of hosts and endpoints over
+// the limit of EnableStats.
+const OtherStats = "other"
+
+// DefaultStatsLimit is limit of hosts and endpoints of EnableStats.
+const DefaultStatsLimit = 1000
+
+type latencyStats struct {
+	mu        sync.Mutex
+	limit     int
+	hosts     map[string]*histogram
+	endpoints map[string]*histogram
+	counters  map[string]*Counters
+}
+
+func newLatencyStats(limit int) *latencyStats {
+	return &latencyStats{
+		limit:     limit,
+		hosts:     make(map[string]*histogram),
+		endpoints: make(map[string]*histogram),
+		counters:  make(map[string]*Counters),
+	}
+}
+
+// EnableStats starts collecting statistics returned by Stats, for up to
+// limit hosts and endpoints, DefaultStatsLimit if limit is not positive.
+// Others are counted under OtherStats.
+func (c *Client) EnableStats(limit int) *Client {
+	if limit <= 0 {
+		limit = DefaultStatsLimit
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = newLatencyStats(limit)
+	return c
+}
+
+// statsKey returns k, or OtherStats if m is full and has no k.
+func statsKey[V any](m map[string]V, k string, limit int) string {
+	if _, ok := m[k]; !ok && len(m) >= limit {
+		return OtherStats
+	}
+	return k
+}
+
+// endpointKey returns key of endpoint statistics of request.
+func endpointKey(method, endpoint string) string {
+	if i := strings.IndexAny(endpoint, "?#"); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return method + " " + endpoint
+}
+
+func (s *latencyStats) count(endpoint string, failed, retry bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoint = statsKey(s.counters, endpoint, s.limit)
+	c, ok := s.counters[endpoint]
+	if !ok {
+		c = &Counters{}
+		s.counters[endpoint] = c
+	}
+	c.Requests++
+	if failed {
+		c.Errors++
+	}
+	if retry {
+		c.Retries++
+	}
+}
+
+func (s *latencyStats) observe(host, endpoint string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host = statsKey(s.hosts, host, s.limit)
+	h, ok := s.hosts[host]
+	if !ok {
+		h = newHistogram()
+		s.hosts[host] = h
+	}
+	h.observe(d)
+
+	endpoint = statsKey(s.endpoints, endpoint, s.limit)
+	e, ok := s.endpoints[endpoint]
+	if !ok {
+		e = newHistogram()
+		s.endpoints[endpoint] = e
+	}
+	e.observe(d)
+}
+
+func (s *latencyStats) violation(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoint = statsKey(s.counters, endpoint, s.limit)
+	c, ok := s.counters[endpoint]
+	if !ok {
+		c = &Counters{}
+		s.counters[endpoint] = c
+	}
+	c.Violations++
+}
+
+func (s *latencyStats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Stats{
+		Hosts:     make(map[string]LatencyStats, len(s.hosts)),
+		Endpoints: make(map[string]LatencyStats, len(s.endpoints)),
+		Counters:  make(map[string]Counters, len(s.counters)),
+	}
+	for k, h := range s.hosts {
+		st.Hosts[k] = h.snapshot()
+	}
+	for k, h := range s.endpoints {
+		st.Endpoints[k] = h.snapshot()
+	}
+	for k, c := range s.counters {
+		st.Counters[k] = *c
+	}
+	return st
+}
+
+// Stats returns statistics of requests sent by the client, empty
+// unless EnableStats is called. Failed requests are not included in
+// latencies.
+func (c *Client) Stats() Stats {
+	c.mu.RLock()
+	s := c.stats
+	c.mu.RUnlock()
+
+	if s == nil {
+		return Stats{}
+	}
+	return s.snapshot()
+}
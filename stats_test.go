@@ -0,0 +1,47 @@
+package restreq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestStatsOptIn(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	if _, err := c.New("/a").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if st := c.Stats(); len(st.Counters) != 0 || len(st.Hosts) != 0 {
+		t.Errorf("stats collected without EnableStats: %+v", st)
+	}
+}
+
+func TestStatsLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL).EnableStats(3)
+	for i := 0; i < 10; i++ {
+		if _, err := c.New("/users/" + strconv.Itoa(i) + "?q=1").Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.New("/users/0?q=2").Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	st := c.Stats()
+	if len(st.Counters) != 4 || len(st.Endpoints) != 4 {
+		t.Fatalf("got %d counters and %d endpoints, want 4", len(st.Counters), len(st.Endpoints))
+	}
+	if n := st.Counters["GET /users/0"].Requests; n != 2 {
+		t.Errorf("GET /users/0 requests = %d, want 2", n)
+	}
+	if n := st.Counters[OtherStats].Requests; n != 7 {
+		t.Errorf("other requests = %d, want 7", n)
+	}
+}
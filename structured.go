@@ -0,0 +1,527 @@
+package restreq
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SFToken is token of structured field (RFC 8941), e.g. gzip or foo/bar,
+// serialized without quotes, unlike string.
+type SFToken string
+
+// SFParam is parameter of structured field item or inner list.
+type SFParam struct {
+	Key   string
+	Value any
+}
+
+// SFParams are parameters in order of the field.
+type SFParams []SFParam
+
+// Get returns value of parameter key.
+func (p SFParams) Get(key string) (any, bool) {
+	for _, v := range p {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SFItem is item or inner list of structured field. Value is one of
+// int64, float64, string, SFToken, []byte, bool, or []SFItem
+// for inner list. Int is also accepted when formatting.
+type SFItem struct {
+	Value  any
+	Params SFParams
+}
+
+// SFDictMember is member of structured field dictionary.
+type SFDictMember struct {
+	Key  string
+	Item SFItem
+}
+
+// SFDict is structured field dictionary, members are in order of the field.
+type SFDict []SFDictMember
+
+// Get returns member key.
+func (d SFDict) Get(key string) (SFItem, bool) {
+	for _, m := range d {
+		if m.Key == key {
+			return m.Item, true
+		}
+	}
+	return SFItem{}, false
+}
+
+// ParseSFItem parses structured field item, e.g. Priority member u=1.
+func ParseSFItem(s string) (SFItem, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	it, err := p.item()
+	if err == nil && p.s != "" {
+		err = p.errorf("unexpected %q", p.s[0])
+	}
+	return it, err
+}
+
+// ParseSFList parses structured field list, e.g. "a";q=1, (b c).
+func ParseSFList(s string) ([]SFItem, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	var l []SFItem
+	for p.s != "" {
+		it, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, it)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// ParseSFDict parses structured field dictionary, e.g. u=1, i.
+// Member without value is true. Duplicate keys keep the last value.
+func ParseSFDict(s string) (SFDict, error) {
+	p := &sfParser{s: strings.Trim(s, " ")}
+	var d SFDict
+	for p.s != "" {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+
+		var it SFItem
+		if p.consume('=') {
+			if it, err = p.member(); err != nil {
+				return nil, err
+			}
+		} else {
+			it.Value = true
+			if it.Params, err = p.params(); err != nil {
+				return nil, err
+			}
+		}
+
+		replaced := false
+		for i := range d {
+			if d[i].Key == key {
+				d[i].Item, replaced = it, true
+			}
+		}
+		if !replaced {
+			d = append(d, SFDictMember{key, it})
+		}
+
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// HeaderList parses all values of header name as structured field list.
+func (r *Response) HeaderList(name string) ([]SFItem, error) {
+	return ParseSFList(strings.Join(r.Response.Header.Values(name), ", "))
+}
+
+// HeaderDict parses all values of header name as structured field dictionary.
+func (r *Response) HeaderDict(name string) (SFDict, error) {
+	return ParseSFDict(strings.Join(r.Response.Header.Values(name), ", "))
+}
+
+// FormatSFItem serializes structured field item.
+func FormatSFItem(it SFItem) (string, error) {
+	var b strings.Builder
+	if err := writeSFMember(&b, it); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// FormatSFList serializes structured field list.
+func FormatSFList(l []SFItem) (string, error) {
+	var b strings.Builder
+	for i, it := range l {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeSFMember(&b, it); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// FormatSFDict serializes structured field dictionary.
+// Member with true value is written as bare key.
+func FormatSFDict(d SFDict) (string, error) {
+	var b strings.Builder
+	for i, m := range d {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if !validSFKey(m.Key) {
+			return "", fmt.Errorf("sf: invalid key %q", m.Key)
+		}
+		b.WriteString(m.Key)
+		if m.Item.Value == true {
+			if err := writeSFParams(&b, m.Item.Params); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeSFMember(&b, m.Item); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func writeSFMember(b *strings.Builder, it SFItem) error {
+	if l, ok := it.Value.([]SFItem); ok {
+		b.WriteByte('(')
+		for i, v := range l {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			if _, ok := v.Value.([]SFItem); ok {
+				return errors.New("sf: nested inner list")
+			}
+			if err := writeSFMember(b, v); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+	} else if err := writeSFBare(b, it.Value); err != nil {
+		return err
+	}
+	return writeSFParams(b, it.Params)
+}
+
+func writeSFParams(b *strings.Builder, params SFParams) error {
+	for _, p := range params {
+		if !validSFKey(p.Key) {
+			return fmt.Errorf("sf: invalid key %q", p.Key)
+		}
+		b.WriteByte(';')
+		b.WriteString(p.Key)
+		if p.Value == true {
+			continue
+		}
+		b.WriteByte('=')
+		if err := writeSFBare(b, p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const sfMaxInt = 999999999999999
+
+func writeSFBare(b *strings.Builder, v any) error {
+	switch x := v.(type) {
+	case int:
+		return writeSFBare(b, int64(x))
+	case int64:
+		if x > sfMaxInt || x < -sfMaxInt {
+			return fmt.Errorf("sf: integer %d out of range", x)
+		}
+		b.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		x = math.RoundToEven(x*1000) / 1000
+		if math.IsNaN(x) || math.Abs(x) >= 1e12 {
+			return fmt.Errorf("sf: decimal %v out of range", x)
+		}
+		s := strconv.FormatFloat(x, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		b.WriteString(s)
+	case string:
+		for i := 0; i < len(x); i++ {
+			if x[i] < 0x20 || x[i] > 0x7e {
+				return fmt.Errorf("sf: invalid character in string %q", x)
+			}
+		}
+		b.WriteString(quoteSFString(x))
+	case SFToken:
+		if !validSFToken(string(x)) {
+			return fmt.Errorf("sf: invalid token %q", x)
+		}
+		b.WriteString(string(x))
+	case []byte:
+		b.WriteString(":" + base64.StdEncoding.EncodeToString(x) + ":")
+	case bool:
+		if x {
+			b.WriteString("?1")
+		} else {
+			b.WriteString("?0")
+		}
+	default:
+		return fmt.Errorf("sf: unsupported value type %T", v)
+	}
+	return nil
+}
+
+func validSFKey(s string) bool {
+	if s == "" || !(isLower(s[0]) || s[0] == '*') {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isKeyChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func validSFToken(s string) bool {
+	if s == "" || !(isAlpha(s[0]) || s[0] == '*') {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+func isAlpha(c byte) bool { return isLower(c) || 'A' <= c && c <= 'Z' }
+
+func isKeyChar(c byte) bool {
+	return isLower(c) || isDigit(c) || strings.IndexByte("_-.*", c) >= 0
+}
+
+func isTokenChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~:/", c) >= 0
+}
+
+type sfParser struct {
+	s string
+}
+
+func (p *sfParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("sf: "+format, a...)
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if p.s != "" && p.s[0] == c {
+		p.s = p.s[1:]
+		return true
+	}
+	return false
+}
+
+// next skips comma between members of list or dictionary.
+func (p *sfParser) next() error {
+	p.s = strings.TrimLeft(p.s, " \t")
+	if p.s == "" {
+		return nil
+	}
+	if !p.consume(',') {
+		return p.errorf("expected comma, got %q", p.s[0])
+	}
+	p.s = strings.TrimLeft(p.s, " \t")
+	if p.s == "" {
+		return p.errorf("trailing comma")
+	}
+	return nil
+}
+
+// member parses item or inner list.
+func (p *sfParser) member() (SFItem, error) {
+	if !p.consume('(') {
+		return p.item()
+	}
+
+	l := []SFItem{}
+	for {
+		p.s = strings.TrimLeft(p.s, " ")
+		if p.consume(')') {
+			break
+		}
+		it, err := p.item()
+		if err != nil {
+			return SFItem{}, err
+		}
+		l = append(l, it)
+		if p.s != "" && p.s[0] != ' ' && p.s[0] != ')' {
+			return SFItem{}, p.errorf("invalid inner list")
+		}
+	}
+
+	params, err := p.params()
+	return SFItem{Value: l, Params: params}, err
+}
+
+func (p *sfParser) item() (SFItem, error) {
+	v, err := p.bare()
+	if err != nil {
+		return SFItem{}, err
+	}
+	params, err := p.params()
+	return SFItem{Value: v, Params: params}, err
+}
+
+func (p *sfParser) params() (SFParams, error) {
+	var params SFParams
+	for p.consume(';') {
+		p.s = strings.TrimLeft(p.s, " ")
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var v any = true
+		if p.consume('=') {
+			if v, err = p.bare(); err != nil {
+				return nil, err
+			}
+		}
+
+		replaced := false
+		for i := range params {
+			if params[i].Key == key {
+				params[i].Value, replaced = v, true
+			}
+		}
+		if !replaced {
+			params = append(params, SFParam{key, v})
+		}
+	}
+	return params, nil
+}
+
+func (p *sfParser) key() (string, error) {
+	if p.s == "" || !(isLower(p.s[0]) || p.s[0] == '*') {
+		return "", p.errorf("invalid key")
+	}
+	i := 1
+	for i < len(p.s) && isKeyChar(p.s[i]) {
+		i++
+	}
+	key := p.s[:i]
+	p.s = p.s[i:]
+	return key, nil
+}
+
+func (p *sfParser) bare() (any, error) {
+	if p.s == "" {
+		return nil, p.errorf("unexpected end")
+	}
+	switch c := p.s[0]; {
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		v, rest, err := parseSFString(p.s)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		for i := 0; i < len(v); i++ {
+			if v[i] < 0x20 || v[i] > 0x7e {
+				return nil, p.errorf("invalid character in string")
+			}
+		}
+		p.s = rest
+		return v, nil
+	case c == ':':
+		end := strings.IndexByte(p.s[1:], ':')
+		if end < 0 {
+			return nil, p.errorf("unterminated byte sequence")
+		}
+		b, err := base64.StdEncoding.DecodeString(p.s[1 : end+1])
+		if err != nil {
+			return nil, p.errorf("invalid byte sequence")
+		}
+		p.s = p.s[end+2:]
+		return b, nil
+	case c == '?':
+		if len(p.s) < 2 || p.s[1] != '0' && p.s[1] != '1' {
+			return nil, p.errorf("invalid boolean")
+		}
+		v := p.s[1] == '1'
+		p.s = p.s[2:]
+		return v, nil
+	case isAlpha(c) || c == '*':
+		i := 1
+		for i < len(p.s) && isTokenChar(p.s[i]) {
+			i++
+		}
+		t := SFToken(p.s[:i])
+		p.s = p.s[i:]
+		return t, nil
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *sfParser) number() (any, error) {
+	i := 0
+	if p.s[0] == '-' {
+		i++
+	}
+	start, dot := i, -1
+	for ; i < len(p.s); i++ {
+		c := p.s[i]
+		if c == '.' && dot < 0 {
+			dot = i
+			continue
+		}
+		if !isDigit(c) {
+			break
+		}
+	}
+	num := p.s[:i]
+
+	switch {
+	case i == start || dot == start:
+		return nil, p.errorf("invalid number")
+	case dot < 0:
+		if i-start > 15 {
+			return nil, p.errorf("integer too long")
+		}
+		p.s = p.s[i:]
+		return strconv.ParseInt(num, 10, 64)
+	case dot-start > 12 || i-dot-1 > 3 || i-dot == 1:
+		return nil, p.errorf("invalid decimal")
+	}
+	p.s = p.s[i:]
+	return strconv.ParseFloat(num, 64)
+}
+
+func quoteSFString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parseSFString parses quoted string at start of s.
+func parseSFString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", s, errors.New("expected string")
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 == len(s) || s[i+1] != '\\' && s[i+1] != '"' {
+				return "", s, errors.New("invalid escape in string")
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s, errors.New("unterminated string")
+}
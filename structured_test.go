@@ -0,0 +1,116 @@
+package restreq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSFDict(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`u=1, i`, `u=1, i`},
+		{`a=?0,  b;x="y" ,c=(1 2.5);p`, `a=?0, b;x="y", c=(1 2.5);p`},
+		{`a=1, a=2`, `a=2`},
+		{`b=:aGk=:, t=foo/bar`, `b=:aGk=:, t=foo/bar`},
+		{`s="a\"b\\c"`, `s="a\"b\\c"`},
+		{`n=-999999999999999, d=-0.5`, `n=-999999999999999, d=-0.5`},
+		{``, ``},
+	}
+	for _, tt := range tests {
+		d, err := ParseSFDict(tt.in)
+		if err != nil {
+			t.Errorf("ParseSFDict(%q): %v", tt.in, err)
+			continue
+		}
+		got, err := FormatSFDict(d)
+		if err != nil || got != tt.want {
+			t.Errorf("FormatSFDict(ParseSFDict(%q)) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseSFInvalid(t *testing.T) {
+	for _, s := range []string{
+		`A=1`, `a=`, `a=1,`, `a=1 b=2`, `a="x`, `a="\x"`, `a=:abc`, `a=?2`,
+		`a=1.`, `a=1.2345`, `a=1234567890123456`, `a=(1 (2))`, `a=(1`, `a=é`,
+	} {
+		if d, err := ParseSFDict(s); err == nil {
+			t.Errorf("ParseSFDict(%q) = %v, want error", s, d)
+		}
+	}
+}
+
+func TestParseSFList(t *testing.T) {
+	l, err := ParseSFList(`"a";q=1, (b c);x, ?1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SFItem{
+		{Value: "a", Params: SFParams{{"q", int64(1)}}},
+		{Value: []SFItem{{Value: SFToken("b")}, {Value: SFToken("c")}}, Params: SFParams{{"x", true}}},
+		{Value: true},
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("ParseSFList = %#v, want %#v", l, want)
+	}
+}
+
+func TestFormatSFInvalid(t *testing.T) {
+	for _, it := range []SFItem{
+		{Value: int64(1e15)},
+		{Value: "\n"},
+		{Value: SFToken("1a")},
+		{Value: 1.0, Params: SFParams{{"A", true}}},
+		{Value: struct{}{}},
+		{Value: []SFItem{{Value: []SFItem{}}}},
+	} {
+		if s, err := FormatSFItem(it); err == nil {
+			t.Errorf("FormatSFItem(%#v) = %q, want error", it, s)
+		}
+	}
+}
+
+func FuzzParseSFDict(f *testing.F) {
+	for _, s := range []string{`u=1, i`, `a=(1 "2";x);y=?1`, `b=:aGk=:`, `d=1.5, t=a/b`} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := ParseSFDict(s)
+		if err != nil {
+			return
+		}
+		out, err := FormatSFDict(d)
+		if err != nil {
+			t.Fatalf("FormatSFDict(ParseSFDict(%q)): %v", s, err)
+		}
+		d2, err := ParseSFDict(out)
+		if err != nil {
+			t.Fatalf("ParseSFDict(%q): %v", out, err)
+		}
+		if out2, _ := FormatSFDict(d2); out2 != out {
+			t.Fatalf("serialization of %q is not stable: %q, %q", s, out, out2)
+		}
+	})
+}
+
+func FuzzParseSFList(f *testing.F) {
+	for _, s := range []string{`"a";q=1, (b c)`, `?0, -1.25, *tok`} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		l, err := ParseSFList(s)
+		if err != nil {
+			return
+		}
+		out, err := FormatSFList(l)
+		if err != nil {
+			t.Fatalf("FormatSFList(ParseSFList(%q)): %v", s, err)
+		}
+		l2, err := ParseSFList(out)
+		if err != nil || !reflect.DeepEqual(l, l2) {
+			t.Fatalf("ParseSFList(%q) = %#v, %v, want %#v", out, l2, err, l)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package restreq
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timings are durations of stages of the request, collected
+// when profiling is enabled.
+type Timings struct {
+	// Build is time of building the request with encoding and
+	// compression of the body, without before hooks.
+	Build time.Duration
+	// BeforeHooks is time of before request hooks.
+	BeforeHooks time.Duration
+	// RateLimit is time waiting for rate limiter.
+	RateLimit time.Duration
+	// Network is time until response headers arrived.
+	Network time.Duration
+	// Body is time of reading the body, including decompression
+	// done by transport. It is zero with WithBodyReader.
+	Body time.Duration
+	// AfterHooks is time of after response hooks.
+	AfterHooks time.Duration
+	// Decode is total time of Decode and DecodeJSON, DecodeXML,
+	// DecodeMsgPack and DecodeCBOR calls on the response.
+	Decode time.Duration
+}
+
+// EnableProfiling makes the request collect stage timings,
+// returned by Response.Timings.
+func (r *Request) EnableProfiling() requester {
+	r.profiling = true
+	return r
+}
+
+// EnableProfiling makes every request collect stage timings.
+func (c *Client) EnableProfiling() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.profiling = true
+	return c
+}
+
+// Timings returns stage timings of the last attempt, which are
+// zero if profiling is not enabled.
+func (r *Response) Timings() Timings {
+	if r.timings == nil {
+		return Timings{}
+	}
+	return *r.timings
+}
+
+type timingsKey struct{}
+
+// withTimings adds timings of an attempt to ctx, if profiling is enabled.
+func (r *Request) withTimings(ctx context.Context) context.Context {
+	if !r.profiling {
+		return ctx
+	}
+	return context.WithValue(ctx, timingsKey{}, &Timings{})
+}
+
+// timingsOf returns timings of the request, nil if not profiled.
+func timingsOf(req *http.Request) *Timings {
+	t, _ := req.Context().Value(timingsKey{}).(*Timings)
+	return t
+}
+
+// observeDecode adds time since start to decode time of the response.
+func (r *Response) observeDecode(start time.Time) {
+	if r.timings != nil {
+		r.timings.Decode += time.Since(start)
+	}
+}
@@ -0,0 +1,152 @@
+package restreq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// SetTLSConfig sets TLS config of the client transports, e.g. to pin
+// versions and cipher suites. It replaces TLS settings set before,
+// except session cache of SetTLSSessionCache and pins of
+// SetCertificatePinning, which are checked after VerifyConnection
+// of cfg. The config is cloned, nil resets TLS settings to defaults.
+// Set fields of TLSConfig of profiles override it.
+func (c *Client) SetTLSConfig(cfg *tls.Config) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eachTLSTransport(func(t *http.Transport) {
+		prev := t.TLSClientConfig
+		if cfg != nil {
+			t.TLSClientConfig = cfg.Clone()
+		} else {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		if prev != nil && t.TLSClientConfig.ClientSessionCache == nil {
+			t.TLSClientConfig.ClientSessionCache = prev.ClientSessionCache
+		}
+		if c.pins != nil {
+			c.applyPins(t.TLSClientConfig, t.TLSClientConfig.VerifyConnection)
+		}
+	})
+	return c
+}
+
+// SetTLSMinVersion sets minimum TLS version, e.g. tls.VersionTLS13.
+func (c *Client) SetTLSMinVersion(v uint16) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eachTLSConfig(func(cfg *tls.Config) {
+		cfg.MinVersion = v
+	})
+	return c
+}
+
+// SetClientCertificate loads certificate and key from PEM files and
+// sends it to servers requiring mutual TLS. Load error is returned by
+// requests of the client.
+func (c *Client) SetClientCertificate(certFile, keyFile string) *Client {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.setErr(fmt.Errorf("client certificate: %w", err))
+		return c
+	}
+	return c.SetClientCertificates(cert)
+}
+
+// SetClientCertificates sets certificates sent to servers requiring
+// mutual TLS, unless a profile sets its own.
+func (c *Client) SetClientCertificates(certs ...tls.Certificate) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eachTLSConfig(func(cfg *tls.Config) {
+		cfg.Certificates = certs
+	})
+	return c
+}
+
+// SetRootCAs sets CAs used to verify server certificates instead
+// of system ones.
+func (c *Client) SetRootCAs(pool *x509.CertPool) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eachTLSConfig(func(cfg *tls.Config) {
+		cfg.RootCAs = pool
+	})
+	return c
+}
+
+// SetRootCAFromFile sets CAs loaded from PEM file, used to verify
+// server certificates instead of system ones. Load error is returned
+// by requests of the client.
+func (c *Client) SetRootCAFromFile(path string) *Client {
+	pem, err := os.ReadFile(path)
+	if err == nil {
+		pool := x509.NewCertPool()
+		if pool.AppendCertsFromPEM(pem) {
+			return c.SetRootCAs(pool)
+		}
+		err = errors.New("no certificates found")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setErr(fmt.Errorf("root CA %s: %w", path, err))
+	return c
+}
+
+// SetTLSInsecureSkipVerify disables verification of server
+// certificates and host names. It is DANGEROUS, anyone on the network
+// can intercept the requests. Use it only with self-signed certificates
+// in development, SetRootCAs is safer.
+func (c *Client) SetTLSInsecureSkipVerify() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eachTLSConfig(func(cfg *tls.Config) {
+		cfg.InsecureSkipVerify = true
+	})
+	return c
+}
+
+// setErr keeps the first error, returned by requests of the client.
+func (c *Client) setErr(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// eachTLSConfig calls f with TLS config of transports of the client
+// and its quirks, created if missing.
+func (c *Client) eachTLSConfig(f func(*tls.Config)) {
+	c.eachTLSTransport(func(t *http.Transport) {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		f(t.TLSClientConfig)
+	})
+}
+
+// eachTLSTransport calls f with transports of the client and its
+// quirks and closes their idle connections, made with old settings.
+// Profiles are rebuilt with their TLSConfig over the new settings.
+func (c *Client) eachTLSTransport(f func(*http.Transport)) {
+	c.resetProfiles()
+	clients := []*http.Client{c.httpClient}
+	for _, q := range c.quirks {
+		if q.client != nil {
+			clients = append(clients, q.client)
+		}
+	}
+
+	for _, hc := range clients {
+		if t, ok := hc.Transport.(*http.Transport); ok {
+			f(t)
+			t.CloseIdleConnections()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package restreq
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// TLSStats count TLS handshakes of a Client, counted after
+// SetTLSSessionCache is called.
+type TLSStats struct {
+	Handshakes int64
+	Resumed    int64
+}
+
+// ResumptionRate returns ratio of Resumed to Handshakes.
+func (s TLSStats) ResumptionRate() float64 {
+	if s.Handshakes == 0 {
+		return 0
+	}
+	return float64(s.Resumed) / float64(s.Handshakes)
+}
+
+type tlsSession struct {
+	cache      tls.ClientSessionCache
+	handshakes int64
+	resumed    int64
+}
+
+// SetTLSSessionCache sets LRU cache of TLS sessions with capacity
+// size, 64 if size is not positive, used for session resumption of
+// new connections. It also counts handshakes, see TLSStats.
+// 0-RTT early data is not supported by crypto/tls, so it is not sent.
+func (c *Client) SetTLSSessionCache(size int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tlsSession == nil {
+		c.tlsSession = &tlsSession{}
+	}
+	c.tlsSession.cache = tls.NewLRUClientSessionCache(size)
+	c.eachTransport(c.tlsSession.apply)
+	return c
+}
+
+// TLSStats returns counts of TLS handshakes.
+func (c *Client) TLSStats() TLSStats {
+	c.mu.RLock()
+	s := c.tlsSession
+	c.mu.RUnlock()
+
+	if s == nil {
+		return TLSStats{}
+	}
+	return TLSStats{
+		Handshakes: atomic.LoadInt64(&s.handshakes),
+		Resumed:    atomic.LoadInt64(&s.resumed),
+	}
+}
+
+// apply sets session cache of t.
+func (s *tlsSession) apply(t *http.Transport) {
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.ClientSessionCache = s.cache
+}
+
+// trace counts TLS handshakes of connections dialed for requests
+// with the returned context.
+func (s *tlsSession) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&s.handshakes, 1)
+			if cs.DidResume {
+				atomic.AddInt64(&s.resumed, 1)
+			}
+		},
+	})
+}
@@ -0,0 +1,142 @@
+package restreq
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta is how long before expiry the token is refreshed.
+const tokenExpiryDelta = 30 * time.Second
+
+// Token is access token kept in TokenCache.
+// Zero Expiry means the token does not expire.
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	Expiry      time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports if the token is set and does not expire soon.
+func (t Token) Valid() bool {
+	return t.AccessToken != "" && (t.Expiry.IsZero() || time.Until(t.Expiry) > tokenExpiryDelta)
+}
+
+// TokenCache keeps tokens by key, e.g. in memory or in file,
+// so they survive restarts and are shared between processes.
+type TokenCache interface {
+	// Get returns token of key, ok is false if there is none.
+	Get(ctx context.Context, key string) (t Token, ok bool, err error)
+	Put(ctx context.Context, key string, t Token) error
+}
+
+// TokenLocker is implemented by caches shared between processes.
+// The token is refreshed with the key locked, so only one process
+// fetches it and the others read it from the cache.
+type TokenLocker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// MemoryTokenCache keeps tokens in memory.
+type MemoryTokenCache struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+}
+
+// NewMemoryTokenCache creates empty memory cache.
+func NewMemoryTokenCache() *MemoryTokenCache {
+	return &MemoryTokenCache{tokens: make(map[string]Token)}
+}
+
+// Get returns token of key.
+func (c *MemoryTokenCache) Get(_ context.Context, key string) (Token, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.tokens[key]
+	return t, ok, nil
+}
+
+// Put sets token of key.
+func (c *MemoryTokenCache) Put(_ context.Context, key string, t Token) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[key] = t
+	return nil
+}
+
+// SetTokenCache sets cache of tokens of SetOAuth2ClientCredentials.
+func (c *Client) SetTokenCache(cache TokenCache) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokenCache = cache
+	if t, ok := c.bearer.(*cachedToken); ok && t.oauth2 {
+		t.setCache(cache)
+	}
+	return c
+}
+
+// CachedTokenProvider returns token provider keeping tokens returned
+// by fetch in cache under key. New token is fetched when the cached one
+// is missing or expires soon. Concurrent requests wait for a single
+// fetch, and for TokenLocker so do other processes.
+func CachedTokenProvider(key string, cache TokenCache, fetch func(ctx context.Context) (Token, error)) TokenProvider {
+	return &cachedToken{key: key, cache: cache, fetch: fetch}
+}
+
+type cachedToken struct {
+	key    string
+	fetch  func(ctx context.Context) (Token, error)
+	oauth2 bool
+
+	mu    sync.Mutex
+	cache TokenCache
+	token Token
+}
+
+func (c *cachedToken) setCache(cache TokenCache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = cache
+}
+
+// Token returns token kept in memory while valid, then cached token,
+// or fetches new one. Errors of reading the cache are treated as
+// missing token, and errors of writing are ignored, so the cache
+// can't break requests.
+func (c *cachedToken) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.Valid() {
+		return c.token.AccessToken, nil
+	}
+	if t, ok := c.cached(ctx); ok {
+		c.token = t
+		return t.AccessToken, nil
+	}
+
+	if l, ok := c.cache.(TokenLocker); ok {
+		unlock, err := l.Lock(ctx, c.key)
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+
+		if t, ok := c.cached(ctx); ok {
+			c.token = t
+			return t.AccessToken, nil
+		}
+	}
+
+	t, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.cache.Put(ctx, c.key, t)
+	c.token = t
+	return t.AccessToken, nil
+}
+
+func (c *cachedToken) cached(ctx context.Context) (Token, bool) {
+	t, ok, err := c.cache.Get(ctx, c.key)
+	return t, err == nil && ok && t.Valid()
+}
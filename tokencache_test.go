@@ -0,0 +1,52 @@
+package restreq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type countingTokenCache struct {
+	*MemoryTokenCache
+	gets int
+}
+
+func (c *countingTokenCache) Get(ctx context.Context, key string) (Token, bool, error) {
+	c.gets++
+	return c.MemoryTokenCache.Get(ctx, key)
+}
+
+func TestCachedTokenKeepsValidToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		expiry  time.Duration
+		cached  bool
+		fetches int
+		gets    int
+	}{
+		{"fetched", time.Hour, false, 1, 1},
+		{"from cache", time.Hour, true, 0, 1},
+		{"expiring", tokenExpiryDelta / 2, false, 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &countingTokenCache{MemoryTokenCache: NewMemoryTokenCache()}
+			if tt.cached {
+				cache.Put(context.Background(), "k", Token{AccessToken: "cached", Expiry: time.Now().Add(tt.expiry)})
+			}
+			fetches := 0
+			p := CachedTokenProvider("k", cache, func(ctx context.Context) (Token, error) {
+				fetches++
+				return Token{AccessToken: "fetched", Expiry: time.Now().Add(tt.expiry)}, nil
+			})
+			for i := 0; i < 3; i++ {
+				if _, err := p.Token(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if fetches != tt.fetches || cache.gets != tt.gets {
+				t.Errorf("fetches = %d, cache reads = %d, want %d, %d", fetches, cache.gets, tt.fetches, tt.gets)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Package tus is a client of the tus resumable upload protocol
+(https://tus.io), version 1.0.0, built on restreq.
+
+Creation, offset recovery with HEAD and the checksum extension are
+supported. Failed PATCH requests are not repeated blindly, the offset
+stored by the server is read with HEAD and the upload continues from it.
+
+	u := tus.New(nil, "https://example.com/files/").SetChunkSize(8 << 20)
+
+	up, err := u.Create(ctx, size, map[string]string{"filename": "video.mp4"})
+	if err == nil {
+		err = u.Upload(ctx, up, f)
+	}
+
+	// after a failure or restart
+	err = u.Resume(ctx, up.URL, f)
+*/
+package tus
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scootpl/restreq"
+)
+
+// Version of the protocol.
+const Version = "1.0.0"
+
+// StatusChecksumMismatch is returned by the server when the chunk
+// checksum does not match.
+const StatusChecksumMismatch = 460
+
+// ErrOffsetMismatch is returned when the server reports offset
+// different from the uploaded one.
+var ErrOffsetMismatch = errors.New("tus: offset mismatch")
+
+// Upload is a created upload.
+type Upload struct {
+	URL    string
+	Size   int64
+	Offset int64
+}
+
+// Uploader uploads files to a tus endpoint.
+type Uploader struct {
+	client    *restreq.Client
+	endpoint  string
+	chunkSize int64
+	checksum  bool
+	retries   int
+}
+
+// New creates uploader. Requests are created by client, if not nil,
+// so they share its defaults, e.g. auth headers.
+func New(client *restreq.Client, endpoint string) *Uploader {
+	return &Uploader{
+		client:    client,
+		endpoint:  endpoint,
+		chunkSize: 4 << 20,
+		retries:   3,
+	}
+}
+
+// SetChunkSize sets size of a single PATCH request.
+func (u *Uploader) SetChunkSize(n int64) *Uploader {
+	u.chunkSize = n
+	return u
+}
+
+// SetChecksum enables checksum extension with sha1.
+// Chunks rejected with mismatched checksum are sent again.
+func (u *Uploader) SetChecksum(on bool) *Uploader {
+	u.checksum = on
+	return u
+}
+
+// SetRetryCount sets number of retries of a single request.
+// A chunk is sent again at most n times, after offset recovery.
+func (u *Uploader) SetRetryCount(n int) *Uploader {
+	u.retries = n
+	return u
+}
+
+// Create creates upload of size bytes with metadata.
+func (u *Uploader) Create(ctx context.Context, size int64, metadata map[string]string) (*Upload, error) {
+	r := u.request(ctx, u.endpoint, nil).
+		AddHeader("Upload-Length", strconv.FormatInt(size, 10))
+
+	if len(metadata) > 0 {
+		r.AddHeader("Upload-Metadata", encodeMetadata(metadata))
+	}
+
+	resp, err := r.Post()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("tus: create: unexpected status %d", resp.StatusCode)
+	}
+
+	loc, err := resolve(u.endpoint, resp.Header("Location"))
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{URL: loc, Size: size}, nil
+}
+
+// Offset returns number of bytes stored by the server.
+func (u *Uploader) Offset(ctx context.Context, uploadURL string) (int64, error) {
+	resp, err := u.request(ctx, uploadURL, nil).Head()
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return 0, fmt.Errorf("tus: head: unexpected status %d", resp.StatusCode)
+	}
+	return strconv.ParseInt(resp.Header("Upload-Offset"), 10, 64)
+}
+
+// Upload sends r starting at up.Offset, which is updated after
+// every chunk. r must be positioned at up.Offset.
+func (u *Uploader) Upload(ctx context.Context, up *Upload, r io.Reader) error {
+	buf := make([]byte, u.chunkSize)
+
+	for up.Offset < up.Size {
+		n, err := io.ReadFull(r, buf[:chunkLen(u.chunkSize, up.Size-up.Offset)])
+		if err != nil {
+			return err
+		}
+		if err := u.sendChunk(ctx, up, buf[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendChunk sends chunk starting at up.Offset. When PATCH fails with
+// a retryable error, the offset is recovered with HEAD and the rest
+// of the chunk is sent from it.
+func (u *Uploader) sendChunk(ctx context.Context, up *Upload, chunk []byte) error {
+	start, end := up.Offset, up.Offset+int64(len(chunk))
+
+	for attempt := 0; ; attempt++ {
+		offset, retry, err := u.patch(ctx, up.URL, up.Offset, chunk[up.Offset-start:])
+		if err == nil {
+			if offset != end {
+				return ErrOffsetMismatch
+			}
+			up.Offset = offset
+			return nil
+		}
+		if !retry || attempt >= u.retries || ctx.Err() != nil {
+			return err
+		}
+
+		if offset, err = u.Offset(ctx, up.URL); err != nil {
+			return err
+		}
+		if offset < start || offset > end {
+			return ErrOffsetMismatch
+		}
+		up.Offset = offset
+		if offset == end {
+			return nil
+		}
+	}
+}
+
+// Resume continues upload of r, which must be seekable,
+// from the offset reported by the server.
+func (u *Uploader) Resume(ctx context.Context, uploadURL string, r io.ReadSeeker) error {
+	resp, err := u.request(ctx, uploadURL, nil).Head()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("tus: head: unexpected status %d", resp.StatusCode)
+	}
+
+	up := &Upload{URL: uploadURL}
+	if up.Offset, err = strconv.ParseInt(resp.Header("Upload-Offset"), 10, 64); err != nil {
+		return err
+	}
+	if up.Size, err = strconv.ParseInt(resp.Header("Upload-Length"), 10, 64); err != nil {
+		return err
+	}
+
+	if _, err := r.Seek(up.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	return u.Upload(ctx, up, r)
+}
+
+// patch sends chunk at offset once, generic retries are disabled,
+// because the server may have stored a part of it. It returns the new
+// offset, or if the error is worth retrying after offset recovery.
+func (u *Uploader) patch(ctx context.Context, uploadURL string, offset int64, chunk []byte) (int64, bool, error) {
+	r := u.request(ctx, uploadURL, chunk).
+		SetRetryCount(0).
+		SetContentType("application/offset+octet-stream").
+		AddHeader("Upload-Offset", strconv.FormatInt(offset, 10))
+
+	if u.checksum {
+		sum := sha1.Sum(chunk)
+		r.AddHeader("Upload-Checksum", "sha1 "+base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	resp, err := r.Patch()
+	if err != nil {
+		return 0, true, err
+	}
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusNoContent:
+		offset, err := strconv.ParseInt(resp.Header("Upload-Offset"), 10, 64)
+		return offset, false, err
+	case code == http.StatusConflict:
+		return 0, true, ErrOffsetMismatch
+	case code == StatusChecksumMismatch || code == http.StatusTooManyRequests || code >= 500:
+		return 0, true, fmt.Errorf("tus: patch: unexpected status %d", code)
+	}
+	return 0, false, fmt.Errorf("tus: patch: unexpected status %d", resp.StatusCode)
+}
+
+// request creates request sending body verbatim.
+func (u *Uploader) request(ctx context.Context, url string, body []byte) *restreq.Request {
+	var r *restreq.Request
+	if u.client != nil {
+		r = u.client.New(url)
+	} else {
+		r = restreq.New(url)
+	}
+
+	r.Context(ctx).
+		SetRetryCount(u.retries).
+		AddHeader("Tus-Resumable", Version).
+		SetBody(body)
+	return r
+}
+
+func encodeMetadata(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + " " + base64.StdEncoding.EncodeToString([]byte(m[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func resolve(base, loc string) (string, error) {
+	if loc == "" {
+		return "", errors.New("tus: create: missing Location")
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
+}
+
+func chunkLen(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// server is a tus endpoint storing a single upload. fail is called
+// with every PATCH and the body read, it can break the response after
+// the body is stored.
+type server struct {
+	data    []byte
+	size    int64
+	patches int
+	fail    func(w http.ResponseWriter, patch int, body []byte) bool
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", Version)
+	switch r.Method {
+	case http.MethodPost:
+		s.size, _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		w.Header().Set("Location", "/files/1")
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.Header().Set("Upload-Length", strconv.FormatInt(s.size, 10))
+	case http.MethodPatch:
+		s.patches++
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(len(s.data)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if s.fail != nil && s.fail(w, s.patches, body) {
+			return
+		}
+		s.data = append(s.data, body...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestUploadRecoversOffset(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	tests := []struct {
+		name    string
+		fail    func(s *server) func(http.ResponseWriter, int, []byte) bool
+		patches int
+	}{
+		{"no failure", nil, 3},
+		{
+			name: "partial chunk stored",
+			fail: func(s *server) func(http.ResponseWriter, int, []byte) bool {
+				return func(w http.ResponseWriter, patch int, body []byte) bool {
+					if patch != 2 {
+						return false
+					}
+					s.data = append(s.data, body[:3]...)
+					w.WriteHeader(http.StatusInternalServerError)
+					return true
+				}
+			},
+			patches: 4,
+		},
+		{
+			name: "response lost",
+			fail: func(s *server) func(http.ResponseWriter, int, []byte) bool {
+				return func(w http.ResponseWriter, patch int, body []byte) bool {
+					if patch != 1 {
+						return false
+					}
+					s.data = append(s.data, body...)
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return true
+				}
+			},
+			patches: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{}
+			if tt.fail != nil {
+				s.fail = tt.fail(s)
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			u := New(nil, srv.URL+"/files/").SetChunkSize(8)
+			up, err := u.Create(context.Background(), int64(len(content)), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := u.Upload(context.Background(), up, bytes.NewReader(content)); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(s.data, content) || s.patches != tt.patches {
+				t.Errorf("stored %q with %d patches, want %d", s.data, s.patches, tt.patches)
+			}
+		})
+	}
+}
+
+func TestUploadPermanentError(t *testing.T) {
+	s := &server{fail: func(w http.ResponseWriter, patch int, body []byte) bool {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return true
+	}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	u := New(nil, srv.URL+"/files/")
+	up := &Upload{URL: srv.URL + "/files/1", Size: 4}
+	if err := u.Upload(context.Background(), up, bytes.NewReader([]byte("data"))); err == nil {
+		t.Fatal("upload succeeded")
+	}
+	if s.patches != 1 {
+		t.Errorf("patches = %d, want 1", s.patches)
+	}
+}
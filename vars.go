@@ -0,0 +1,165 @@
+package restreq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// Vars is a variable store shared by requests.
+// Values are set by Extract and used in {{name}} templates.
+//
+// Templates are expanded only in requests with a store: set with
+// SetVars or Extract, or created by a client after its store was
+// used by Client.Vars or Extract. Other requests send {{name}} as is.
+type Vars struct {
+	mu sync.RWMutex
+	m  map[string]any
+
+	// used is set when the store of a client is used,
+	// which enables templates of its requests.
+	used atomic.Bool
+}
+
+// NewVars creates empty store.
+func NewVars() *Vars {
+	return &Vars{m: make(map[string]any)}
+}
+
+// Get returns value of variable.
+func (v *Vars) Get(name string) (any, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	x, ok := v.m[name]
+	return x, ok
+}
+
+// Set sets value of variable.
+func (v *Vars) Set(name string, value any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.m[name] = value
+}
+
+func (v *Vars) enable() {
+	v.used.Store(true)
+}
+
+func (v *Vars) enabled() bool {
+	return v != nil && v.used.Load()
+}
+
+// SetVars sets variable store. With store set, {{name}} templates are
+// replaced in URL, header values and string values of AddJSONKeyValue.
+// A JSON value which is exactly one template keeps the variable type.
+func (r *Request) SetVars(v *Vars) requester {
+	r.vars = v
+	return r
+}
+
+// Extract stores values from JSON response in variable store, set
+// by SetVars or the store of the client, which is required. Keys are
+// variable names, values are paths like auth.access_token or
+// items[0].id. The store of the client is used by following requests
+// of the client. If a path is missing, request returns the response
+// and an error.
+func (r *Request) Extract(m map[string]string) requester {
+	if r.vars == nil && r.clientVars != nil {
+		r.vars = r.clientVars
+		r.vars.enable()
+	}
+	if r.extract == nil {
+		r.extract = make(map[string]string)
+	}
+	for k, v := range m {
+		r.extract[k] = v
+	}
+	return r
+}
+
+var templateRe = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// expand replaces templates in s.
+func (r *Request) expand(s string) (string, error) {
+	if r.vars == nil {
+		return s, nil
+	}
+
+	var err error
+	s = templateRe.ReplaceAllStringFunc(s, func(m string) string {
+		name := templateRe.FindStringSubmatch(m)[1]
+		v, ok := r.vars.Get(name)
+		if !ok {
+			err = fmt.Errorf("undefined variable: %s", name)
+			return m
+		}
+		return fmt.Sprint(v)
+	})
+	return s, err
+}
+
+// expandValue replaces templates in string JSON value,
+// also in maps and slices built from key paths.
+func (r *Request) expandValue(v any) (any, error) {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			var err error
+			if m[k], err = r.expandValue(e); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []any:
+		a := make([]any, len(x))
+		for i, e := range x {
+			var err error
+			if a[i], err = r.expandValue(e); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+
+	s, ok := v.(string)
+	if !ok || r.vars == nil {
+		return v, nil
+	}
+
+	if m := templateRe.FindStringSubmatch(s); m != nil && m[0] == s {
+		if x, ok := r.vars.Get(m[1]); ok {
+			return x, nil
+		}
+	}
+	return r.expand(s)
+}
+
+func (r *Request) extractVars(resp *Response) error {
+	if r.vars == nil {
+		return errors.New("extract: variable store is not set")
+	}
+	if resp.streamed {
+		return errors.New("extract: not supported with WithBodyReader")
+	}
+
+	var v any
+	d := json.NewDecoder(bytes.NewReader(resp.Body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return fmt.Errorf("extract: %w", err)
+	}
+
+	for name, path := range r.extract {
+		x, err := lookupPath(v, path)
+		if err != nil {
+			return fmt.Errorf("extract: %w", err)
+		}
+		r.vars.Set(name, x)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package restreq
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTemplatesOptIn(t *testing.T) {
+	c := NewClient("http://example.com")
+	req, err := c.New("/items/{{x}}").
+		AddHeader("X-Tag", "{{x}}").
+		AddQueryParam("q", "{{x}}").
+		AddJSONKeyValue("name", "{{x}}").
+		Build("POST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := requestBody(req)
+	body = bytes.TrimSpace(body)
+	if req.URL.Path != "/items/{{x}}" || req.Header.Get("X-Tag") != "{{x}}" ||
+		req.URL.Query().Get("q") != "{{x}}" || string(body) != `{"name":"{{x}}"}` {
+		t.Errorf("templates expanded: %q %v %q", req.URL.Path, req.Header, body)
+	}
+
+	c.Vars().Set("x", 1)
+	req, err = c.New("/items/{{x}}").Build("GET")
+	if err != nil || req.URL.Path != "/items/1" {
+		t.Errorf("enabled store: %v, %v", req, err)
+	}
+}
+
+func TestExtractClientVars(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			fmt.Fprint(w, `{"auth":{"access_token":"t1"}}`)
+			return
+		}
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	if _, err := c.New("/login").Extract(map[string]string{"token": "auth.access_token"}).Post(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.New("/items").AddHeader("Authorization", "Bearer {{token}}").Get()
+	if err != nil || string(resp.Body) != "Bearer t1" {
+		t.Errorf("Authorization = %q, %v", resp.Body, err)
+	}
+
+	if _, err := New(srv.URL + "/login").Extract(map[string]string{"token": "auth.access_token"}).Post(); err == nil {
+		t.Error("extracted without store")
+	}
+}
@@ -0,0 +1,127 @@
+package restreq
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type versionKind int
+
+const (
+	versionHeader versionKind = iota
+	versionMediaType
+	versionPath
+	versionQuery
+)
+
+// VersionLocation tells where SetAPIVersion puts the version.
+type VersionLocation struct {
+	kind versionKind
+	name string
+}
+
+// VersionHeader puts version to header, e.g. X-API-Version: 2.
+func VersionHeader(name string) VersionLocation {
+	return VersionLocation{kind: versionHeader, name: name}
+}
+
+// VersionMediaType puts version to Accept header as vendor media type,
+// e.g. Accept: application/vnd.foo.v2+json for vendor foo and version v2.
+func VersionMediaType(vendor string) VersionLocation {
+	return VersionLocation{kind: versionMediaType, name: vendor}
+}
+
+// VersionPath inserts version to URL path after path of the client
+// base URL, e.g. /api/v2/items for base URL https://example.com/api.
+func VersionPath() VersionLocation {
+	return VersionLocation{kind: versionPath}
+}
+
+// VersionQuery adds version as query parameter, e.g. ?api-version=2.
+func VersionQuery(param string) VersionLocation {
+	return VersionLocation{kind: versionQuery, name: param}
+}
+
+// SetAPIVersion sets API version sent in the given location.
+// Location without header, vendor or parameter name is an error
+// returned by the request.
+func (r *Request) SetAPIVersion(v string, in VersionLocation) requester {
+	if in.kind != versionPath && in.name == "" {
+		r.setErr(errors.New("api version: empty name of location"))
+		return r
+	}
+	r.apiVersion = v
+	r.apiVersionIn = in
+	return r
+}
+
+func (r *Request) applyAPIVersion(req *http.Request) {
+	if r.apiVersion == "" {
+		return
+	}
+
+	in := r.apiVersionIn
+	switch in.kind {
+	case versionHeader:
+		req.Header.Set(in.name, r.apiVersion)
+	case versionMediaType:
+		req.Header.Set("Accept", "application/vnd."+in.name+"."+r.apiVersion+"+json")
+	case versionPath:
+		var base, rawBase string
+		if u, err := url.Parse(r.baseURL); err == nil && r.baseURL != "" {
+			base, rawBase = u.Path, u.EscapedPath()
+		}
+		req.URL.Path = insertVersion(req.URL.Path, base, r.apiVersion)
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = insertVersion(req.URL.RawPath, rawBase, r.apiVersion)
+		}
+	case versionQuery:
+		q := req.URL.Query()
+		q.Set(in.name, r.apiVersion)
+		req.URL.RawQuery = q.Encode()
+	}
+}
+
+// insertVersion inserts segment v to path after base, or at the
+// start if path is not under base.
+func insertVersion(path, base, v string) string {
+	base = strings.TrimRight(base, "/")
+	if !strings.HasPrefix(path, base) || len(path) > len(base) && path[len(base)] != '/' {
+		base = ""
+	}
+	return base + "/" + v + "/" + strings.TrimLeft(path[len(base):], "/")
+}
+
+// versionHeaders are headers commonly used by servers to report version.
+var versionHeaders = []string{"Api-Version", "X-Api-Version", "X-Version"}
+
+// APIVersion returns version reported by the server in a version header
+// or in vendor media type of Content-Type, e.g. v2 for
+// application/vnd.foo.v2+json. It returns empty string if not found.
+func (r *Response) APIVersion() string {
+	for _, h := range versionHeaders {
+		if v := r.Header(h); v != "" {
+			return v
+		}
+	}
+
+	mt, _, err := mime.ParseMediaType(r.Header("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	_, sub, _ := strings.Cut(mt, "/")
+	if !strings.HasPrefix(sub, "vnd.") {
+		return ""
+	}
+	sub, _, _ = strings.Cut(sub, "+")
+
+	parts := strings.Split(sub, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
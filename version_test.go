@@ -0,0 +1,36 @@
+package restreq
+
+import "testing"
+
+func TestAPIVersionPath(t *testing.T) {
+	tests := []struct {
+		base string
+		path string
+		want string
+	}{
+		{"https://example.com", "/items", "/v2/items"},
+		{"https://example.com/api", "/items", "/api/v2/items"},
+		{"https://example.com/api/", "items/1", "/api/v2/items/1"},
+		{"https://example.com/api", "https://other.com/apis/x", "/v2/apis/x"},
+		{"https://example.com/a%2Fb", "/items", "/a%2Fb/v2/items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base+" "+tt.path, func(t *testing.T) {
+			req, err := NewClient(tt.base).New(tt.path).SetAPIVersion("v2", VersionPath()).Build("GET")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.EscapedPath(); got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIVersionEmptyLocation(t *testing.T) {
+	for _, in := range []VersionLocation{{}, VersionHeader(""), VersionMediaType(""), VersionQuery("")} {
+		if _, err := New("https://example.com").SetAPIVersion("2", in).Build("GET"); err == nil {
+			t.Errorf("%+v: no error", in)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package restreq
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Warning is parsed Warning header (RFC 7234), e.g.
+// 110 cache.example.com "Response is stale".
+type Warning struct {
+	Code  int
+	Agent string
+	Text  string
+	// Date is zero if not sent.
+	Date time.Time
+}
+
+// String formats w as value of Warning header. Empty Agent is sent as -.
+func (w Warning) String() string {
+	agent := w.Agent
+	if agent == "" {
+		agent = "-"
+	}
+	s := fmt.Sprintf("%03d %s %s", w.Code, agent, quoteWarning(w.Text))
+	if !w.Date.IsZero() {
+		s += " " + quoteWarning(w.Date.UTC().Format(http.TimeFormat))
+	}
+	return s
+}
+
+// AddWarning adds Warning header to the response, e.g. code 110
+// Response is stale added by a cache serving stale response.
+func (r *Response) AddWarning(w Warning) {
+	if r.Response.Header == nil {
+		r.Response.Header = http.Header{}
+	}
+	r.Response.Header.Add("Warning", w.String())
+}
+
+// Warnings returns parsed Warning headers. Malformed values are skipped.
+func (r *Response) Warnings() []Warning {
+	var ws []Warning
+	for _, v := range r.Response.Header.Values("Warning") {
+		ws = append(ws, parseWarnings(v)...)
+	}
+	return ws
+}
+
+func parseWarnings(s string) []Warning {
+	var ws []Warning
+
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			return ws
+		}
+
+		if w, rest, ok := parseWarning(s); ok {
+			ws = append(ws, w)
+			s = rest
+			continue
+		}
+
+		// skip malformed warning
+		i := strings.IndexByte(s, ',')
+		if i < 0 {
+			return ws
+		}
+		s = s[i+1:]
+	}
+}
+
+func parseWarning(s string) (Warning, string, bool) {
+	w := Warning{}
+
+	code, s, ok := strings.Cut(s, " ")
+	if !ok || len(code) != 3 || !isDigit(code[0]) || !isDigit(code[1]) || !isDigit(code[2]) {
+		return w, s, false
+	}
+	w.Code, _ = strconv.Atoi(code)
+
+	if w.Agent, s, ok = strings.Cut(strings.TrimLeft(s, " "), " "); !ok {
+		return w, s, false
+	}
+
+	text, s, ok := quoted(strings.TrimLeft(s, " "))
+	if !ok {
+		return w, s, false
+	}
+	w.Text = text
+
+	if d := strings.TrimLeft(s, " "); strings.HasPrefix(d, `"`) {
+		if date, rest, ok := quoted(d); ok {
+			if t, err := http.ParseTime(date); err == nil {
+				w.Date = t
+			}
+			s = rest
+		}
+	}
+
+	return w, s, true
+}
+
+// quoteWarning returns s as quoted string.
+func quoteWarning(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// quoted returns content of a quoted string at the beginning of s
+// and the rest of s.
+func quoted(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", s, false
+	}
+
+	b := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", s, false
+}
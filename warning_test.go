@@ -0,0 +1,72 @@
+package restreq
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWarnings(t *testing.T) {
+	date := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want []Warning
+	}{
+		{`110 cache.example.com "Response is stale"`, []Warning{{Code: 110, Agent: "cache.example.com", Text: "Response is stale"}}},
+		{
+			`299 - "Deprecated \"v1\"" "Wed, 21 Oct 2015 07:28:00 GMT", 112 - "Disconnected"`,
+			[]Warning{{Code: 299, Agent: "-", Text: `Deprecated "v1"`, Date: date}, {Code: 112, Agent: "-", Text: "Disconnected"}},
+		},
+		{`199 - "a, b" "not a date"`, []Warning{{Code: 199, Agent: "-", Text: "a, b"}}},
+		{`99 - "short", 1100 - "long", -12 - "sign", 214 - "ok"`, []Warning{{Code: 214, Agent: "-", Text: "ok"}}},
+		{`110 agent unquoted`, nil},
+		{`110 - "unterminated`, nil},
+		{``, nil},
+	}
+	for _, tt := range tests {
+		if got := parseWarnings(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWarnings(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResponseWarnings(t *testing.T) {
+	h := http.Header{}
+	h.Add("Warning", `110 - "Response is stale"`)
+	h.Add("Warning", `113 - "Heuristic expiration"`)
+	r := &Response{Response: &http.Response{Header: h}}
+	if ws := r.Warnings(); len(ws) != 2 || ws[0].Code != 110 || ws[1].Code != 113 {
+		t.Errorf("Warnings() = %v", ws)
+	}
+}
+
+func TestAddWarning(t *testing.T) {
+	date := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	r := &Response{Response: &http.Response{}}
+	r.AddWarning(Warning{Code: 110, Text: "Response is stale"})
+	r.AddWarning(Warning{Code: 299, Agent: "api", Text: `Deprecated "v1"`, Date: date})
+
+	want := []string{`110 - "Response is stale"`, `299 api "Deprecated \"v1\"" "Wed, 21 Oct 2015 07:28:00 GMT"`}
+	if got := r.Response.Header.Values("Warning"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Warning = %q, want %q", got, want)
+	}
+	if ws := r.Warnings(); len(ws) != 2 || ws[0].Agent != "-" || ws[1].Text != `Deprecated "v1"` || !ws[1].Date.Equal(date) {
+		t.Errorf("Warnings() = %v", ws)
+	}
+}
+
+func FuzzParseWarnings(f *testing.F) {
+	f.Add(`110 cache.example.com "Response is stale" "Wed, 21 Oct 2015 07:28:00 GMT"`)
+	f.Add(`299 - "a\"b", 112 - "c"`)
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, w := range parseWarnings(s) {
+			if w.Code < 0 || w.Code > 999 || w.Agent == "" {
+				t.Fatalf("parseWarnings(%q): invalid warning %+v", s, w)
+			}
+			if got := parseWarnings(w.String()); len(got) != 1 || got[0] != w {
+				t.Fatalf("parseWarnings(%q) = %v, want %v", w.String(), got, w)
+			}
+		}
+	})
+}
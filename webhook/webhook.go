@@ -0,0 +1,382 @@
+/*
+Package webhook delivers outbound webhooks on top of restreq.
+
+Every delivery is signed with HMAC-SHA256 over the timestamp and the body,
+retried with a per-endpoint schedule and kept in a Store until it is
+delivered or the schedule is exhausted.
+
+	s := webhook.NewSender(secret, webhook.NewMemoryStore()).
+		OnStatus(func(r webhook.Result) {
+			log.Printf("%s: %s", r.Delivery.ID, r.Status)
+		})
+
+	err := s.Send(ctx, "https://example.com/hook", payload)
+
+	go s.Run(ctx, time.Minute)
+*/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scootpl/restreq"
+)
+
+// Default header names.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	IDHeader        = "X-Webhook-ID"
+)
+
+// Delivery is a webhook waiting to be delivered.
+type Delivery struct {
+	ID          string          `json:"id"`
+	URL         string          `json:"url"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempt     int             `json:"attempt"`
+	NextAttempt time.Time       `json:"next_attempt"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Store persists pending deliveries, so they survive restarts.
+type Store interface {
+	// Save adds or replaces delivery.
+	Save(ctx context.Context, d *Delivery) error
+	// Due returns deliveries with NextAttempt before or equal to now.
+	Due(ctx context.Context, now time.Time) ([]*Delivery, error)
+	// Delete removes delivery.
+	Delete(ctx context.Context, id string) error
+}
+
+// Status of a delivery attempt.
+type Status int
+
+const (
+	// Delivered means the endpoint responded with 2xx.
+	Delivered Status = iota
+	// Retrying means the attempt failed and another one is scheduled.
+	Retrying
+	// Failed means the attempt failed and the schedule is exhausted.
+	Failed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Delivered:
+		return "delivered"
+	case Retrying:
+		return "retrying"
+	case Failed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Result is passed to the status callback after every attempt.
+type Result struct {
+	Delivery   *Delivery
+	Status     Status
+	StatusCode int
+	Err        error
+}
+
+// Sender signs and delivers webhooks.
+type Sender struct {
+	secret          []byte
+	store           Store
+	schedule        []time.Duration
+	endpoints       map[string][]time.Duration
+	onStatus        func(Result)
+	signatureHeader string
+	timestampHeader string
+	timeout         int
+	now             func() time.Time
+}
+
+// NewSender creates sender with the default schedule,
+// see ExponentialSchedule.
+func NewSender(secret []byte, store Store) *Sender {
+	return &Sender{
+		secret:          secret,
+		store:           store,
+		schedule:        ExponentialSchedule(30*time.Second, 6*time.Hour, 12),
+		endpoints:       make(map[string][]time.Duration),
+		signatureHeader: SignatureHeader,
+		timestampHeader: TimestampHeader,
+		timeout:         30,
+		now:             time.Now,
+	}
+}
+
+// ExponentialSchedule returns n waits starting from base and doubling
+// up to max.
+func ExponentialSchedule(base, max time.Duration, n int) []time.Duration {
+	s := make([]time.Duration, n)
+	d := base
+	for i := range s {
+		if d > max {
+			d = max
+		}
+		s[i] = d
+		d *= 2
+	}
+	return s
+}
+
+// SetSchedule sets the default waits between attempts.
+// The number of waits is the number of retries.
+func (s *Sender) SetSchedule(waits ...time.Duration) *Sender {
+	s.schedule = waits
+	return s
+}
+
+// SetEndpointSchedule sets the waits between attempts for a single URL.
+func (s *Sender) SetEndpointSchedule(url string, waits ...time.Duration) *Sender {
+	s.endpoints[url] = waits
+	return s
+}
+
+// SetHeaders sets names of the signature and timestamp headers.
+func (s *Sender) SetHeaders(signature, timestamp string) *Sender {
+	s.signatureHeader = signature
+	s.timestampHeader = timestamp
+	return s
+}
+
+// SetTimeoutSec sets timeout of a single attempt.
+func (s *Sender) SetTimeoutSec(t int) *Sender {
+	s.timeout = t
+	return s
+}
+
+// OnStatus sets callback invoked after every attempt.
+func (s *Sender) OnStatus(f func(Result)) *Sender {
+	s.onStatus = f
+	return s
+}
+
+// Send stores the delivery and makes the first attempt.
+// The returned error is about invalid payload or storing, delivery
+// status is reported to the OnStatus callback. Until the first attempt
+// finishes, the delivery is stored as due after the first retry wait,
+// so Flush does not deliver it again.
+func (s *Sender) Send(ctx context.Context, url string, payload json.RawMessage) error {
+	if _, err := encode(payload); err != nil {
+		return err
+	}
+	id, err := newID()
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	d := &Delivery{
+		ID:          id,
+		URL:         url,
+		Payload:     payload,
+		NextAttempt: now.Add(s.inFlight(url)),
+		CreatedAt:   now,
+	}
+
+	if err := s.store.Save(ctx, d); err != nil {
+		return err
+	}
+
+	return s.attempt(ctx, d)
+}
+
+// Flush attempts all due deliveries once.
+func (s *Sender) Flush(ctx context.Context) error {
+	due, err := s.store.Due(ctx, s.now())
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.attempt(ctx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run calls Flush every interval until ctx is done.
+func (s *Sender) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if err := s.Flush(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// scheduleOf returns the waits between attempts for url.
+func (s *Sender) scheduleOf(url string) []time.Duration {
+	if schedule, ok := s.endpoints[url]; ok {
+		return schedule
+	}
+	return s.schedule
+}
+
+// inFlight returns how long a delivery to url being attempted is not
+// due: the first retry wait, or the attempt timeout if longer.
+func (s *Sender) inFlight(url string) time.Duration {
+	d := time.Duration(s.timeout) * time.Second
+	if schedule := s.scheduleOf(url); len(schedule) > 0 && schedule[0] > d {
+		d = schedule[0]
+	}
+	return d
+}
+
+func (s *Sender) attempt(ctx context.Context, d *Delivery) error {
+	body, err := encode(d.Payload)
+	if err != nil {
+		// Invalid payload fails every attempt, so it is not retried.
+		return s.finish(ctx, Result{Delivery: d, Status: Failed, Err: err})
+	}
+	ts := strconv.FormatInt(s.now().Unix(), 10)
+
+	resp, err := restreq.New(d.URL).
+		Context(ctx).
+		SetTimeoutSec(s.timeout).
+		SetContentTypeJSON().
+		AddHeader(IDHeader, d.ID).
+		AddHeader(s.timestampHeader, ts).
+		AddHeader(s.signatureHeader, "sha256="+Sign(s.secret, ts, body)).
+		SetBody(body).
+		Post()
+
+	res := Result{Delivery: d, Err: err}
+	if err == nil {
+		res.StatusCode = resp.StatusCode
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			res.Err = fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+		}
+	}
+
+	if res.Err == nil {
+		res.Status = Delivered
+	} else {
+		d.Attempt++
+		if schedule := s.scheduleOf(d.URL); d.Attempt > len(schedule) {
+			res.Status = Failed
+		} else {
+			res.Status = Retrying
+			d.NextAttempt = s.now().Add(schedule[d.Attempt-1])
+		}
+	}
+	return s.finish(ctx, res)
+}
+
+// finish stores or deletes delivery of res and reports res to the
+// status callback.
+func (s *Sender) finish(ctx context.Context, res Result) error {
+	var err error
+	if res.Status == Retrying {
+		err = s.store.Save(ctx, res.Delivery)
+	} else {
+		err = s.store.Delete(ctx, res.Delivery.ID)
+	}
+
+	if s.onStatus != nil {
+		s.onStatus(res)
+	}
+	return err
+}
+
+// Sign returns hex encoded HMAC-SHA256 of timestamp, a dot and body.
+// Receivers should compute it over the raw request body.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(timestamp))
+	m.Write([]byte{'.'})
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Verify checks signature computed by Sign.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// encode returns payload as sent in request body.
+func encode(p json.RawMessage) ([]byte, error) {
+	w := &bytes.Buffer{}
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	return w.Bytes(), nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryStore keeps deliveries in memory. Pending deliveries are lost
+// on restart, implement Store for durable persistence.
+type MemoryStore struct {
+	mu sync.Mutex
+	m  map[string]Delivery
+}
+
+// NewMemoryStore creates empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{m: make(map[string]Delivery)}
+}
+
+// Save adds or replaces delivery.
+func (m *MemoryStore) Save(_ context.Context, d *Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[d.ID] = *d
+	return nil
+}
+
+// Due returns deliveries with NextAttempt before or equal to now.
+func (m *MemoryStore) Due(_ context.Context, now time.Time) ([]*Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []*Delivery
+	for _, d := range m.m {
+		if !d.NextAttempt.After(now) {
+			d := d
+			due = append(due, &d)
+		}
+	}
+	return due, nil
+}
+
+// Delete removes delivery.
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.m, id)
+	return nil
+}
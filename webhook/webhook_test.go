@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendInFlight(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewSender([]byte("secret"), store)
+
+	deliveries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries++
+		if deliveries == 1 {
+			// Flush during the first attempt must not deliver again.
+			if err := s.Flush(r.Context()); err != nil {
+				t.Error(err)
+			}
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var results []Result
+	s.OnStatus(func(r Result) { results = append(results, r) })
+	if err := s.Send(context.Background(), srv.URL, json.RawMessage(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries != 1 || len(results) != 1 || results[0].Status != Retrying {
+		t.Fatalf("deliveries = %d, results = %+v", deliveries, results)
+	}
+
+	due, _ := store.Due(context.Background(), time.Now().Add(31*time.Second))
+	if len(due) != 1 || due[0].Attempt != 1 {
+		t.Errorf("due = %+v", due)
+	}
+}
+
+func TestSendInvalidPayload(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewSender([]byte("secret"), store)
+	if err := s.Send(context.Background(), "http://example.com", json.RawMessage(`{`)); err == nil {
+		t.Error("invalid payload sent")
+	}
+	if len(store.m) != 0 {
+		t.Errorf("stored %d deliveries", len(store.m))
+	}
+}
+
+func TestInvalidPayloadFails(t *testing.T) {
+	store := NewMemoryStore()
+	d := &Delivery{ID: "1", URL: "http://example.com", Payload: json.RawMessage(`x`)}
+	store.Save(context.Background(), d)
+
+	var res Result
+	s := NewSender([]byte("secret"), store).OnStatus(func(r Result) { res = r })
+	if err := s.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != Failed || res.Err == nil || len(store.m) != 0 {
+		t.Errorf("result = %+v, stored %d", res, len(store.m))
+	}
+}
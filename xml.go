@@ -0,0 +1,54 @@
+package restreq
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// SetXMLPayload encodes struct to XML body and sets Content-Type
+// to application/xml. It replaces JSON body.
+func (r *Request) SetXMLPayload(p any) requester {
+	b, err := xml.Marshal(p)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	r.rawBody = b
+	r.headers["Content-Type"] = "application/xml"
+	return r
+}
+
+// DecodeXML decodes XML
+func (r *Response) DecodeXML(s any) error {
+	if ok, err := r.empty(s); ok {
+		return err
+	}
+	defer r.observeDecode(time.Now())
+	return xml.Unmarshal(r.Body, s)
+}
+
+// DecodeXMLStream calls f for every start element of XML body, so large
+// documents can be processed record by record, e.g. with
+// d.DecodeElement(&item, &start). Elements not decoded by f are
+// traversed and f is called also for their children.
+// It works also with WithBodyReader, decoding the body as it arrives.
+// An error returned by f stops decoding and is returned.
+func (r *Response) DecodeXMLStream(f func(start xml.StartElement, d *xml.Decoder) error) error {
+	d := xml.NewDecoder(r.bodyReader())
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if se, ok := t.(xml.StartElement); ok {
+			if err := f(se, d); err != nil {
+				return err
+			}
+		}
+	}
+}